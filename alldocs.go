@@ -0,0 +1,227 @@
+package couchdb
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+)
+
+// AllDocsOptions represents the query options of _all_docs, _design_docs and _local_docs.
+type AllDocsOptions struct {
+	// Keys returns only the documents with the specified IDs, in the same order.
+	Keys []string
+	// StartKey and EndKey limit the range of document IDs returned.
+	StartKey string
+	EndKey   string
+	// ExclusiveEnd excludes EndKey from the results.
+	ExclusiveEnd bool
+	// IncludeDocs includes the documents in the rows.
+	IncludeDocs bool
+	// Conflicts includes the conflicting revisions in the documents, requires IncludeDocs.
+	Conflicts bool
+	// Descending returns the rows in reverse order, StartKey should then be greater than EndKey.
+	Descending bool
+	// Limit is the maximum number of rows returned, no limit if not positive.
+	Limit int
+	// Skip skips the first rows returned.
+	Skip int
+	// UpdateSeq includes the update sequence of the database in the result.
+	UpdateSeq bool
+}
+
+func (o *AllDocsOptions) params() (url.Values, map[string]interface{}, error) {
+	params := url.Values{}
+	if o == nil {
+		return params, nil, nil
+	}
+
+	if o.StartKey != "" {
+		key, err := json.Marshal(o.StartKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		params.Set("startkey", string(key))
+	}
+	if o.EndKey != "" {
+		key, err := json.Marshal(o.EndKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		params.Set("endkey", string(key))
+	}
+	if o.ExclusiveEnd {
+		params.Set("inclusive_end", "false")
+	}
+	if o.IncludeDocs {
+		params.Set("include_docs", "true")
+	}
+	if o.Conflicts {
+		params.Set("conflicts", "true")
+	}
+	if o.Descending {
+		params.Set("descending", "true")
+	}
+	if o.Limit > 0 {
+		params.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Skip > 0 {
+		params.Set("skip", strconv.Itoa(o.Skip))
+	}
+	if o.UpdateSeq {
+		params.Set("update_seq", "true")
+	}
+
+	if o.Keys != nil {
+		return params, map[string]interface{}{"keys": o.Keys}, nil
+	}
+	return params, nil, nil
+}
+
+// AllDocsValue represents the value of a row of _all_docs.
+type AllDocsValue struct {
+	Rev     string `json:"rev"`
+	Deleted bool   `json:"deleted"`
+}
+
+// AllDocsRow represents a row of _all_docs. Error is set for keys not found,
+// Err is set by IterAllDocs when fetching the rows failed.
+type AllDocsRow struct {
+	ID    string                 `json:"id"`
+	Key   string                 `json:"key"`
+	Value AllDocsValue           `json:"value"`
+	Doc   map[string]interface{} `json:"doc"`
+	Error string                 `json:"error"`
+	Err   error                  `json:"-"`
+}
+
+// AllDocsResult represents the result of _all_docs.
+type AllDocsResult struct {
+	TotalRows int          `json:"total_rows"`
+	Offset    int          `json:"offset"`
+	UpdateSeq Sequence     `json:"update_seq"`
+	Rows      []AllDocsRow `json:"rows"`
+}
+
+// AllDocs returns the documents in the database, nil options returns all the
+// IDs and revisions in one response, use IterAllDocs for large databases.
+func (d *Database) AllDocs(options *AllDocsOptions) (*AllDocsResult, error) {
+	return d.allDocs("_all_docs", options)
+}
+
+// DesignDocs returns the design documents in the database.
+func (d *Database) DesignDocs(options *AllDocsOptions) (*AllDocsResult, error) {
+	return d.allDocs("_design_docs", options)
+}
+
+// LocalDocs returns the local documents in the database.
+func (d *Database) LocalDocs(options *AllDocsOptions) (*AllDocsResult, error) {
+	return d.allDocs("_local_docs", options)
+}
+
+func (d *Database) allDocs(path string, options *AllDocsOptions) (*AllDocsResult, error) {
+	params, body, err := options.params()
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	if body != nil {
+		_, data, err = d.resource.PostJSON(path, nil, body, params)
+	} else {
+		_, data, err = d.resource.GetJSON(path, nil, params)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AllDocsResult{}
+	err = json.Unmarshal(data, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// IterAllDocs returns a channel fetching rows of _all_docs in batches which
+// iterates a row at a time(pagination), the next batch starts from the key of
+// the last row fetched. If fetching fails a row with Err set is sent before the channel closes.
+//
+// options: optional query options, Limit and Skip apply to the whole iteration.
+func (d *Database) IterAllDocs(batch int, options *AllDocsOptions) (<-chan AllDocsRow, error) {
+	if batch <= 0 {
+		return nil, ErrBatchValue
+	}
+
+	opts := AllDocsOptions{}
+	if options != nil {
+		opts = *options
+	}
+	limited := opts.Limit > 0
+	remaining := opts.Limit
+
+	// Row generator
+	rchan := make(chan AllDocsRow)
+	go func() {
+		defer close(rchan)
+
+		if opts.Keys != nil {
+			d.iterKeys(rchan, batch, opts)
+			return
+		}
+
+		for {
+			loopLimit := batch
+			if limited {
+				loopLimit = min(batch, remaining)
+			}
+			// get rows in batch with one extra for start of next batch
+			opts.Limit = loopLimit + 1
+			result, err := d.AllDocs(&opts)
+			if err != nil {
+				rchan <- AllDocsRow{Err: err}
+				return
+			}
+			rows := result.Rows
+
+			// send all rows to channel except the last extra one
+			for _, row := range rows[:min(len(rows), loopLimit)] {
+				rchan <- row
+			}
+
+			if limited {
+				remaining -= min(len(rows), loopLimit)
+			}
+
+			if len(rows) <= loopLimit || (limited && remaining == 0) {
+				return
+			}
+			opts.StartKey = rows[len(rows)-1].Key
+			opts.Skip = 0
+		}
+	}()
+	return rchan, nil
+}
+
+// iterKeys sends the rows of opts.Keys in batches of keys.
+func (d *Database) iterKeys(rchan chan<- AllDocsRow, batch int, opts AllDocsOptions) {
+	keys := opts.Keys
+	if opts.Skip > 0 {
+		keys = keys[min(opts.Skip, len(keys)):]
+	}
+	if opts.Limit > 0 {
+		keys = keys[:min(opts.Limit, len(keys))]
+	}
+	opts.Skip, opts.Limit = 0, 0
+
+	for start := 0; start < len(keys); start += batch {
+		opts.Keys = keys[start:min(start+batch, len(keys))]
+		result, err := d.AllDocs(&opts)
+		if err != nil {
+			rchan <- AllDocsRow{Err: err}
+			return
+		}
+		for _, row := range result.Rows {
+			rchan <- row
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package couchdb
+
+import (
+	"testing"
+)
+
+func TestAllDocs(t *testing.T) {
+	result, err := iterDB.AllDocs(&AllDocsOptions{Keys: []string{"3", "missing", "1"}, IncludeDocs: true})
+	if err != nil {
+		t.Fatal("db all docs error", err)
+	}
+	if len(result.Rows) != 3 {
+		t.Fatalf("all docs rows %d want 3", len(result.Rows))
+	}
+	if result.Rows[0].ID != "3" || result.Rows[0].Doc["num"].(float64) != 1 {
+		t.Errorf("rows[0] %v want id 3 num 1", result.Rows[0])
+	}
+	if result.Rows[1].Error != "not_found" {
+		t.Errorf("rows[1] error %s want not_found", result.Rows[1].Error)
+	}
+
+	result, err = iterDB.AllDocs(&AllDocsOptions{StartKey: "10", EndKey: "12", ExclusiveEnd: true, Descending: false})
+	if err != nil {
+		t.Fatal("db all docs error", err)
+	}
+	if len(result.Rows) != 2 || result.Rows[1].ID != "11" {
+		t.Errorf("all docs rows %v want 10 and 11", result.Rows)
+	}
+	if result.TotalRows != NumDocs+1 {
+		t.Errorf("all docs total rows %d want %d", result.TotalRows, NumDocs+1)
+	}
+
+	design, err := iterDB.DesignDocs(nil)
+	if err != nil {
+		t.Fatal("db design docs error", err)
+	}
+	if len(design.Rows) != 1 || design.Rows[0].ID != "_design/test" {
+		t.Errorf("design docs rows %v want _design/test", design.Rows)
+	}
+}
+
+func TestIterAllDocs(t *testing.T) {
+	for _, tc := range []struct {
+		batch   int
+		options *AllDocsOptions
+		want    int
+	}{
+		{7, nil, NumDocs + 1},
+		{NumDocs + 1, nil, NumDocs + 1},
+		{7, &AllDocsOptions{Limit: 15}, 15},
+		{7, &AllDocsOptions{Descending: true, Limit: 14}, 14},
+		{2, &AllDocsOptions{Keys: []string{"1", "2", "3", "4", "5"}, Skip: 1}, 4},
+	} {
+		rows, err := iterDB.IterAllDocs(tc.batch, tc.options)
+		if err != nil {
+			t.Fatal("db iter all docs error", err)
+		}
+		seen := map[string]bool{}
+		for row := range rows {
+			if row.Err != nil {
+				t.Fatal("iter all docs row error", row.Err)
+			}
+			if seen[row.ID] {
+				t.Errorf("row %s duplicated", row.ID)
+			}
+			seen[row.ID] = true
+		}
+		if len(seen) != tc.want {
+			t.Errorf("batch %d options %+v rows %d want %d", tc.batch, tc.options, len(seen), tc.want)
+		}
+	}
+
+	_, err := iterDB.IterAllDocs(0, nil)
+	if err != ErrBatchValue {
+		t.Errorf("iter all docs error %v want %v", err, ErrBatchValue)
+	}
+}
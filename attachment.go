@@ -0,0 +1,317 @@
+package couchdb
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// AttachmentInfo represents the metadata of an attachment. Digest is in the
+// form of "md5-<base64>" like in _attachments, Length is -1 if unknown.
+type AttachmentInfo struct {
+	Name        string
+	ContentType string
+	Encoding    string
+	Digest      string
+	Length      int64
+}
+
+func attachmentInfo(name string, header http.Header, length int64) *AttachmentInfo {
+	info := &AttachmentInfo{
+		Name:        name,
+		ContentType: header.Get("Content-Type"),
+		Encoding:    header.Get("Content-Encoding"),
+		Length:      length,
+	}
+	if md5 := header.Get("Content-MD5"); md5 != "" {
+		info.Digest = "md5-" + md5
+	} else if etag := strings.Trim(header.Get("ETag"), `"`); etag != "" {
+		info.Digest = "md5-" + etag
+	}
+	return info
+}
+
+func attachmentResource(res *Resource, docid, name string) *Resource {
+	return docResource(docResource(res, docid), name)
+}
+
+// AttachmentInfo returns the metadata of the attachment with a HEAD request,
+// without downloading its content.
+func (d *Database) AttachmentInfo(docid, name string) (*AttachmentInfo, error) {
+	rsp, err := attachmentResource(d.resource, docid, name).stream(context.Background(), http.MethodHead, "", nil, nil, -1, nil)
+	if err != nil {
+		return nil, err
+	}
+	rsp.Body.Close()
+
+	length := int64(-1)
+	if cl := rsp.Header.Get("Content-Length"); cl != "" {
+		length, err = strconv.ParseInt(cl, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return attachmentInfo(name, rsp.Header, length), nil
+}
+
+// DigestMismatchError is returned when the content of a downloaded attachment
+// does not match the digest sent by the server, e.g. it has been truncated.
+type DigestMismatchError struct {
+	Name     string
+	Expected string
+	Actual   string
+}
+
+func (e *DigestMismatchError) Error() string {
+	return fmt.Sprintf("attachment %s digest mismatch: expected %s, got %s", e.Name, e.Expected, e.Actual)
+}
+
+// digestReader computes the md5 digest of the content read and checks it
+// against the expected one once the end is reached.
+type digestReader struct {
+	io.ReadCloser
+	name     string
+	expected string
+	hash     hash.Hash
+}
+
+func (r *digestReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.hash.Write(p[:n])
+	if err == io.EOF {
+		actual := "md5-" + base64.StdEncoding.EncodeToString(r.hash.Sum(nil))
+		if actual != r.expected {
+			return n, &DigestMismatchError{Name: r.name, Expected: r.expected, Actual: actual}
+		}
+	}
+	return n, err
+}
+
+// OpenAttachment returns a reader streaming the content of the attachment along
+// with its metadata. The caller must close the reader. The content is verified
+// against the md5 digest sent by the server, reading to the end fails with a
+// *DigestMismatchError instead of io.EOF if they do not match. Compressed
+// content cannot be verified.
+func (d *Database) OpenAttachment(docid, name string) (io.ReadCloser, *AttachmentInfo, error) {
+	return d.openAttachment(docid, name, nil)
+}
+
+// OpenAttachmentRange is like OpenAttachment but streams only the bytes from
+// start to end inclusive, to the end of the attachment if end is negative.
+// It fails with ErrRequestRangeNotSatisfiable if start is out of range. If the
+// server ignores the Range header, e.g. for compressed attachments, the range
+// is cut from the full content on the client side.
+func (d *Database) OpenAttachmentRange(docid, name string, start, end int64) (io.ReadCloser, *AttachmentInfo, error) {
+	if start < 0 || (end >= 0 && end < start) {
+		return nil, nil, fmt.Errorf("invalid range %d-%d", start, end)
+	}
+	return d.openAttachment(docid, name, &[2]int64{start, end})
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func (d *Database) openAttachment(docid, name string, byteRange *[2]int64) (io.ReadCloser, *AttachmentInfo, error) {
+	header := http.Header{}
+	header.Set("Accept", "*/*")
+	if byteRange != nil {
+		if byteRange[1] >= 0 {
+			header.Set("Range", fmt.Sprintf("bytes=%d-%d", byteRange[0], byteRange[1]))
+		} else {
+			header.Set("Range", fmt.Sprintf("bytes=%d-", byteRange[0]))
+		}
+	}
+
+	rsp, err := attachmentResource(d.resource, docid, name).stream(context.Background(), http.MethodGet, "", header, nil, -1, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info := attachmentInfo(name, rsp.Header, rsp.ContentLength)
+	if byteRange == nil {
+		// the digest is of the identity content
+		if info.Digest == "" || info.Encoding != "" || rsp.Uncompressed {
+			return rsp.Body, info, nil
+		}
+		return &digestReader{ReadCloser: rsp.Body, name: name, expected: info.Digest, hash: md5.New()}, info, nil
+	}
+	if rsp.StatusCode == http.StatusPartialContent {
+		return rsp.Body, info, nil
+	}
+
+	// the server sent the whole content, cut the range out of it
+	_, err = io.CopyN(ioutil.Discard, rsp.Body, byteRange[0])
+	if err == io.EOF {
+		rsp.Body.Close()
+		return nil, nil, ErrRequestRangeNotSatisfiable
+	}
+	if err != nil {
+		rsp.Body.Close()
+		return nil, nil, err
+	}
+
+	var body io.Reader = rsp.Body
+	info.Length = -1
+	if byteRange[1] >= 0 {
+		info.Length = byteRange[1] - byteRange[0] + 1
+		body = io.LimitReader(rsp.Body, info.Length)
+	} else if rsp.ContentLength >= 0 {
+		info.Length = rsp.ContentLength - byteRange[0]
+	}
+	return readCloser{body, rsp.Body}, info, nil
+}
+
+// GetAttachmentTo streams the content of the attachment into w, returns the
+// number of bytes written. It fails with a *DigestMismatchError if the content
+// does not match its digest, in which case w has received corrupted data.
+func (d *Database) GetAttachmentTo(w io.Writer, docid, name string) (int64, error) {
+	body, _, err := d.OpenAttachment(docid, name)
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+	return io.Copy(w, body)
+}
+
+// PutAttachmentFrom uploads the attachment to the specified document, streaming
+// length bytes from r without holding them in memory. If length is negative,
+// r is streamed until EOF with chunked transfer encoding.
+// doc: the document that the attachment belongs to. Must have _id and _rev inside,
+// its _rev is updated after uploaded.
+func (d *Database) PutAttachmentFrom(doc map[string]interface{}, name, mimeType string, r io.Reader, length int64) error {
+	id, _ := doc["_id"].(string)
+	if id == "" {
+		return errors.New("doc _id not existed")
+	}
+	rev, _ := doc["_rev"].(string)
+	if rev == "" {
+		return errors.New("doc _rev not existed")
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", mimeType)
+	params := url.Values{}
+	params.Set("rev", rev)
+
+	body := r
+	if length >= 0 {
+		body = io.LimitReader(r, length)
+	}
+	rsp, err := attachmentResource(d.resource, id, name).stream(context.Background(), http.MethodPut, "", header, body, length, params)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	data, err := ioutil.ReadAll(rsp.Body)
+	if err != nil {
+		return err
+	}
+
+	result, err := parseData(data)
+	if err != nil {
+		return err
+	}
+	doc["_rev"] = result["rev"]
+	return nil
+}
+
+// isInlineAttachment returns true if att is an _attachments entry holding its data.
+func isInlineAttachment(att interface{}) bool {
+	switch a := att.(type) {
+	case Attachment:
+		return a.Data != nil
+	case *Attachment:
+		return a != nil && a.Data != nil
+	case map[string]interface{}:
+		_, ok := a["data"]
+		return ok
+	}
+	return false
+}
+
+// prepareAttachments normalizes the _attachments of doc before it is saved and
+// returns the names of the inline attachments. If doc has a _rev and either some
+// inline attachments, or no _attachments unless dropAttachments is set, the stubs
+// of the attachments existing at that revision are merged in, so that they are
+// not dropped by the update.
+func (d *Database) prepareAttachments(docid string, doc map[string]interface{}) ([]string, error) {
+	atts := map[string]interface{}{}
+	present := false
+	switch v := doc["_attachments"].(type) {
+	case map[string]interface{}:
+		atts, present = v, true
+	case map[string]Attachment:
+		for name, att := range v {
+			atts[name] = att
+		}
+		present = true
+	}
+
+	inline := []string{}
+	for name, att := range atts {
+		if isInlineAttachment(att) {
+			inline = append(inline, name)
+		}
+	}
+
+	rev, _ := doc["_rev"].(string)
+	if docid != "" && rev != "" && (len(inline) > 0 || (!present && !d.dropAttachments)) {
+		existing, err := d.Get(docid, url.Values{"rev": []string{rev}})
+		if err != nil && err != ErrNotFound {
+			return nil, err
+		}
+		stubs, _ := existing["_attachments"].(map[string]interface{})
+		for name, stub := range stubs {
+			if _, ok := atts[name]; !ok {
+				atts[name] = stub
+			}
+		}
+	}
+
+	if present || len(atts) > 0 {
+		doc["_attachments"] = atts
+	}
+	return inline, nil
+}
+
+// stubAttachments replaces the inline attachments of a saved doc with stubs.
+func stubAttachments(doc map[string]interface{}, inline []string) {
+	atts, _ := doc["_attachments"].(map[string]interface{})
+	for _, name := range inline {
+		var contentType string
+		var length int
+		switch a := atts[name].(type) {
+		case Attachment:
+			contentType, length = a.ContentType, len(a.Data)
+		case *Attachment:
+			contentType, length = a.ContentType, len(a.Data)
+		case map[string]interface{}:
+			contentType, _ = a["content_type"].(string)
+			switch data := a["data"].(type) {
+			case []byte:
+				length = len(data)
+			case string:
+				length = base64.StdEncoding.DecodedLen(len(data))
+				if decoded, err := base64.StdEncoding.DecodeString(data); err == nil {
+					length = len(decoded)
+				}
+			}
+		}
+		atts[name] = map[string]interface{}{
+			"stub":         true,
+			"content_type": contentType,
+			"length":       length,
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package couchdb
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestAttachmentStream(t *testing.T) {
+	doc := map[string]interface{}{"_id": "stream"}
+	_, rev, err := testsDB.Save(doc, nil)
+	if err != nil {
+		t.Fatal("db save error", err)
+	}
+	doc["_rev"] = rev
+
+	content := "0123456789abcdefghij"
+	err = testsDB.PutAttachmentFrom(doc, "data.txt", "text/plain", strings.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatal("db put attachment from error", err)
+	}
+	if doc["_rev"] == rev {
+		t.Error("doc _rev not updated")
+	}
+
+	info, err := testsDB.AttachmentInfo("stream", "data.txt")
+	if err != nil {
+		t.Fatal("db attachment info error", err)
+	}
+	if info.Length != int64(len(content)) || !strings.HasPrefix(info.ContentType, "text/plain") || !strings.HasPrefix(info.Digest, "md5-") {
+		t.Errorf("attachment info %+v", info)
+	}
+
+	buf := &bytes.Buffer{}
+	n, err := testsDB.GetAttachmentTo(buf, "stream", "data.txt")
+	if err != nil {
+		t.Fatal("db get attachment to error", err)
+	}
+	if n != int64(len(content)) || buf.String() != content {
+		t.Errorf("attachment %q want %q", buf.String(), content)
+	}
+
+	body, _, err := testsDB.OpenAttachmentRange("stream", "data.txt", 5, 9)
+	if err != nil {
+		t.Fatal("db open attachment range error", err)
+	}
+	data, err := ioutil.ReadAll(body)
+	body.Close()
+	if err != nil {
+		t.Fatal("read attachment error", err)
+	}
+	if string(data) != content[5:10] {
+		t.Errorf("attachment range %q want %q", data, content[5:10])
+	}
+
+	_, _, err = testsDB.OpenAttachmentRange("stream", "data.txt", 100, -1)
+	if err != ErrRequestRangeNotSatisfiable {
+		t.Errorf("open attachment range error %v want %v", err, ErrRequestRangeNotSatisfiable)
+	}
+
+	_, err = testsDB.AttachmentInfo("stream", "missing.txt")
+	if err != ErrNotFound {
+		t.Errorf("attachment info error %v want %v", err, ErrNotFound)
+	}
+
+	// a reader of unknown length is streamed chunked
+	err = testsDB.PutAttachmentFrom(doc, "chunked.txt", "text/plain", io.MultiReader(strings.NewReader(content)), -1)
+	if err != nil {
+		t.Fatal("db put attachment from chunked error", err)
+	}
+	buf.Reset()
+	_, err = testsDB.GetAttachmentTo(buf, "stream", "chunked.txt")
+	if err != nil {
+		t.Fatal("db get attachment to error", err)
+	}
+	if buf.String() != content {
+		t.Errorf("chunked attachment %q want %q", buf.String(), content)
+	}
+}
+
+func TestDigestMismatch(t *testing.T) {
+	content := "truncated content"
+	r := &digestReader{
+		ReadCloser: ioutil.NopCloser(strings.NewReader(content[:9])),
+		name:       "data.txt",
+		expected:   "md5-" + base64.StdEncoding.EncodeToString(md5Sum(content)),
+		hash:       md5.New(),
+	}
+	_, err := ioutil.ReadAll(r)
+	if _, ok := err.(*DigestMismatchError); !ok {
+		t.Errorf("read error %v want *DigestMismatchError", err)
+	}
+
+	r.ReadCloser, r.hash = ioutil.NopCloser(strings.NewReader(content)), md5.New()
+	data, err := ioutil.ReadAll(r)
+	if err != nil || string(data) != content {
+		t.Errorf("read %q error %v want %q", data, err, content)
+	}
+}
+
+func md5Sum(s string) []byte {
+	sum := md5.Sum([]byte(s))
+	return sum[:]
+}
+
+func TestSaveInlineAttachments(t *testing.T) {
+	doc := map[string]interface{}{
+		"_id": "inline",
+		"_attachments": map[string]interface{}{
+			"a.txt": Attachment{ContentType: "text/plain", Data: []byte("first")},
+		},
+	}
+	_, _, err := testsDB.Save(doc, nil)
+	if err != nil {
+		t.Fatal("db save error", err)
+	}
+	stub, _ := doc["_attachments"].(map[string]interface{})["a.txt"].(map[string]interface{})
+	if stub["stub"] != true || stub["length"] != 5 {
+		t.Errorf("attachment stub %v", stub)
+	}
+
+	// saving without _attachments keeps the existing ones
+	delete(doc, "_attachments")
+	doc["field"] = "value"
+	_, _, err = testsDB.Save(doc, nil)
+	if err != nil {
+		t.Fatal("db save error", err)
+	}
+
+	// adding an inline attachment keeps the existing ones too
+	doc["_attachments"] = map[string]interface{}{
+		"b.txt": map[string]interface{}{"content_type": "text/plain", "data": []byte("second")},
+	}
+	_, _, err = testsDB.Save(doc, nil)
+	if err != nil {
+		t.Fatal("db save error", err)
+	}
+
+	for name, content := range map[string]string{"a.txt": "first", "b.txt": "second"} {
+		data, err := testsDB.GetAttachmentID("inline", name)
+		if err != nil {
+			t.Fatal("db get attachment error", err)
+		}
+		if string(data) != content {
+			t.Errorf("attachment %s content %q want %q", name, data, content)
+		}
+	}
+
+	// saving without _attachments drops them once keeping them is disabled
+	testsDB.SetKeepAttachments(false)
+	delete(doc, "_attachments")
+	_, _, err = testsDB.Save(doc, nil)
+	testsDB.SetKeepAttachments(true)
+	if err != nil {
+		t.Fatal("db save error", err)
+	}
+	_, err = testsDB.GetAttachmentID("inline", "a.txt")
+	if err != ErrNotFound {
+		t.Errorf("db get attachment error %v want %v", err, ErrNotFound)
+	}
+}
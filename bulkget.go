@@ -0,0 +1,231 @@
+package couchdb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+var (
+	// ErrDocMissing for documents which do not exist
+	ErrDocMissing = errors.New("document missing")
+	// ErrDocDeleted for documents which have been deleted
+	ErrDocDeleted = errors.New("document deleted")
+)
+
+// BulkGetOptions represents the options of Database.GetMany.
+type BulkGetOptions struct {
+	// Rev maps document IDs to the revisions to fetch, the winning revision is fetched by default.
+	Rev map[string]string
+	// Revs includes the revision history of documents as _revisions.
+	Revs bool
+	// Attachments includes the attachments data inline.
+	Attachments bool
+	// Latest fetches the latest leaf revisions instead of the ones in Rev.
+	Latest bool
+}
+
+// BulkGetResult represents the result of a document fetched by Database.GetMany.
+// Err is ErrDocMissing or ErrDocDeleted if the document is missing or deleted,
+// Doc holds the tombstone if a deleted revision is fetched explicitly.
+type BulkGetResult struct {
+	ID  string
+	Doc map[string]interface{}
+	Err error
+}
+
+// GetMany fetches the documents with the specified IDs in a single request with
+// _bulk_get, and falls back to _all_docs with include_docs on servers that do not
+// support it. The results are in the same order as ids. As _all_docs only returns
+// the winning revisions, the fallback fetches the documents given a revision in
+// Rev, or all of them if Revs is set, with one open_revs request each.
+func (d *Database) GetMany(ids []string, options *BulkGetOptions) ([]BulkGetResult, error) {
+	if options == nil {
+		options = &BulkGetOptions{}
+	}
+	if len(ids) == 0 {
+		return []BulkGetResult{}, nil
+	}
+
+	results, err := d.bulkGet(ids, options)
+	switch err {
+	case ErrNotFound, ErrBadRequest, ErrResourceNotAllowed:
+		return d.allDocsGet(ids, options)
+	}
+	return results, err
+}
+
+func (d *Database) bulkGet(ids []string, options *BulkGetOptions) ([]BulkGetResult, error) {
+	docs := make([]map[string]interface{}, len(ids))
+	for i, id := range ids {
+		docs[i] = map[string]interface{}{"id": id}
+		if rev, ok := options.Rev[id]; ok && rev != "" {
+			docs[i]["rev"] = rev
+		}
+	}
+
+	params := url.Values{}
+	if options.Revs {
+		params.Set("revs", "true")
+	}
+	if options.Attachments {
+		params.Set("attachments", "true")
+	}
+	if options.Latest {
+		params.Set("latest", "true")
+	}
+
+	_, data, err := d.resource.PostJSON("_bulk_get", nil, map[string]interface{}{"docs": docs}, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Results []struct {
+			ID   string `json:"id"`
+			Docs []struct {
+				OK    map[string]interface{} `json:"ok"`
+				Error *struct {
+					Error  string `json:"error"`
+					Reason string `json:"reason"`
+				} `json:"error"`
+			} `json:"docs"`
+		} `json:"results"`
+	}
+	err = json.Unmarshal(data, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]BulkGetResult, len(ids))
+	for i, id := range ids {
+		results[i] = BulkGetResult{ID: id, Err: ErrDocMissing}
+		if i >= len(response.Results) || len(response.Results[i].Docs) == 0 {
+			continue
+		}
+
+		doc := response.Results[i].Docs[0]
+		switch {
+		case doc.OK != nil:
+			results[i].Doc = doc.OK
+			results[i].Err = nil
+			if deleted, _ := doc.OK["_deleted"].(bool); deleted {
+				results[i].Err = ErrDocDeleted
+			}
+		case doc.Error != nil:
+			results[i].Err = bulkGetError(doc.Error.Error, doc.Error.Reason)
+		}
+	}
+	return results, nil
+}
+
+// bulkGetError returns the error of a document which could not be fetched
+// from its error id and reason.
+func bulkGetError(id, reason string) error {
+	switch {
+	case reason == "deleted":
+		return ErrDocDeleted
+	case id == "not_found":
+		return ErrDocMissing
+	case updateErrors[id] != nil:
+		return fmt.Errorf("%w: %s", updateErrors[id], reason)
+	}
+	return fmt.Errorf("%s: %s", id, reason)
+}
+
+// allDocsGet fetches the documents with _all_docs, revisions asked for in
+// options are fetched one by one with open_revs as _all_docs only returns the
+// winners.
+func (d *Database) allDocsGet(ids []string, options *BulkGetOptions) ([]BulkGetResult, error) {
+	body := map[string]interface{}{"keys": ids}
+	params := url.Values{}
+	params.Set("include_docs", "true")
+	if options.Attachments {
+		params.Set("attachments", "true")
+	}
+
+	_, data, err := d.resource.PostJSON("_all_docs", nil, body, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Rows []struct {
+			Key   string `json:"key"`
+			Error string `json:"error"`
+			Value struct {
+				Rev     string `json:"rev"`
+				Deleted bool   `json:"deleted"`
+			} `json:"value"`
+			Doc map[string]interface{} `json:"doc"`
+		} `json:"rows"`
+	}
+	err = json.Unmarshal(data, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]BulkGetResult, len(ids))
+	for i, id := range ids {
+		results[i] = BulkGetResult{ID: id, Err: ErrDocMissing}
+
+		if i >= len(response.Rows) {
+			continue
+		}
+		row := response.Rows[i]
+
+		rev := options.Rev[id]
+		if rev == "" && options.Revs && row.Error == "" && !row.Value.Deleted {
+			rev = row.Value.Rev
+		}
+		if rev != "" {
+			results[i].Doc, results[i].Err = d.openRev(id, rev, options)
+			continue
+		}
+
+		switch {
+		case row.Value.Deleted:
+			results[i].Err = ErrDocDeleted
+		case row.Error == "" && row.Doc != nil:
+			results[i].Doc, results[i].Err = row.Doc, nil
+		case row.Error != "":
+			results[i].Err = bulkGetError(row.Error, "")
+		}
+	}
+	return results, nil
+}
+
+// openRev fetches a revision of the document with open_revs like _bulk_get would.
+func (d *Database) openRev(docid, rev string, options *BulkGetOptions) (map[string]interface{}, error) {
+	revs, err := json.Marshal([]string{rev})
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	if options.Revs {
+		params.Set("revs", "true")
+	}
+	if options.Attachments {
+		params.Set("attachments", "true")
+	}
+	if options.Latest {
+		params.Set("latest", "true")
+	}
+
+	docs, _, err := d.openRevs(docid, string(revs), params)
+	if err == ErrNotFound {
+		return nil, ErrDocMissing
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, ErrDocMissing
+	}
+	doc := docs[0]
+	if deleted, _ := doc["_deleted"].(bool); deleted {
+		return doc, ErrDocDeleted
+	}
+	return doc, nil
+}
@@ -0,0 +1,121 @@
+package couchdb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGetMany(t *testing.T) {
+	_, err := testsDB.Update([]map[string]interface{}{
+		{"_id": "many-1", "num": 1},
+		{"_id": "many-2", "num": 2},
+		{"_id": "many-3", "num": 3},
+	}, nil)
+	if err != nil {
+		t.Fatal("db update error", err)
+	}
+	err = testsDB.Delete("many-3")
+	if err != nil {
+		t.Fatal("db delete error", err)
+	}
+
+	ids := []string{"many-2", "many-missing", "many-3", "many-1"}
+	check := func(name string, results []BulkGetResult) {
+		if len(results) != len(ids) {
+			t.Fatalf("%s results %d want %d", name, len(results), len(ids))
+		}
+		for i, result := range results {
+			if result.ID != ids[i] {
+				t.Errorf("%s results[%d] id %s want %s", name, i, result.ID, ids[i])
+			}
+		}
+		if results[0].Err != nil || results[0].Doc["num"].(float64) != 2 {
+			t.Errorf("%s results[0] %v want num 2", name, results[0])
+		}
+		if results[1].Err != ErrDocMissing {
+			t.Errorf("%s results[1] error %v want %v", name, results[1].Err, ErrDocMissing)
+		}
+		if results[2].Err != ErrDocDeleted {
+			t.Errorf("%s results[2] error %v want %v", name, results[2].Err, ErrDocDeleted)
+		}
+		if results[3].Err != nil || results[3].Doc["num"].(float64) != 1 {
+			t.Errorf("%s results[3] %v want num 1", name, results[3])
+		}
+	}
+
+	results, err := testsDB.GetMany(ids, nil)
+	if err != nil {
+		t.Fatal("db get many error", err)
+	}
+	check("_bulk_get", results)
+
+	results, err = testsDB.allDocsGet(ids, &BulkGetOptions{})
+	if err != nil {
+		t.Fatal("db all docs get error", err)
+	}
+	check("_all_docs", results)
+}
+
+func TestGetManyRevs(t *testing.T) {
+	doc := map[string]interface{}{"_id": "many-revs", "num": 1}
+	_, rev1, err := testsDB.Save(doc, nil)
+	if err != nil {
+		t.Fatal("db save error", err)
+	}
+	doc["num"] = 2
+	_, rev2, err := testsDB.Save(doc, nil)
+	if err != nil {
+		t.Fatal("db save error", err)
+	}
+
+	results, err := testsDB.GetMany([]string{"many-revs"}, &BulkGetOptions{Rev: map[string]string{"many-revs": rev1}, Revs: true})
+	if err != nil {
+		t.Fatal("db get many error", err)
+	}
+	if results[0].Err != nil || results[0].Doc["_rev"] != rev1 {
+		t.Errorf("result %v want rev %s", results[0], rev1)
+	}
+	if _, ok := results[0].Doc["_revisions"]; !ok {
+		t.Error("result has no _revisions")
+	}
+
+	results, err = testsDB.allDocsGet([]string{"many-revs"}, &BulkGetOptions{Rev: map[string]string{"many-revs": rev1}, Revs: true})
+	if err != nil {
+		t.Fatal("db all docs get error", err)
+	}
+	if results[0].Err != nil || results[0].Doc["_rev"] != rev1 {
+		t.Errorf("_all_docs result %v want rev %s", results[0], rev1)
+	}
+	if _, ok := results[0].Doc["_revisions"]; !ok {
+		t.Error("_all_docs result has no _revisions")
+	}
+
+	results, err = testsDB.allDocsGet([]string{"many-revs"}, &BulkGetOptions{Rev: map[string]string{"many-revs": rev1}, Latest: true})
+	if err != nil {
+		t.Fatal("db all docs get error", err)
+	}
+	if results[0].Err != nil || results[0].Doc["_rev"] != rev2 {
+		t.Errorf("_all_docs latest result %v want rev %s", results[0], rev2)
+	}
+}
+
+func TestBulkGetError(t *testing.T) {
+	tests := []struct {
+		id, reason string
+		want       error
+	}{
+		{"not_found", "missing", ErrDocMissing},
+		{"not_found", "deleted", ErrDocDeleted},
+		{"forbidden", "no access", ErrForbidden},
+		{"unauthorized", "no access", ErrUnauthorized},
+	}
+	for _, test := range tests {
+		err := bulkGetError(test.id, test.reason)
+		if !errors.Is(err, test.want) {
+			t.Errorf("bulk get error %s %s = %v want %v", test.id, test.reason, err, test.want)
+		}
+	}
+	if err := bulkGetError("unknown_error", "oops"); err.Error() != "unknown_error: oops" {
+		t.Errorf("bulk get error %v want unknown_error: oops", err)
+	}
+}
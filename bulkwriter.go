@@ -0,0 +1,225 @@
+package couchdb
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrBulkWriterClosed for documents added to a closed BulkWriter
+var ErrBulkWriterClosed = errors.New("bulk writer closed")
+
+const (
+	defaultBulkMaxDocs  = 500
+	defaultBulkMaxBytes = 1 << 20
+	defaultBulkMaxDelay = 100 * time.Millisecond
+)
+
+// BulkWriterOptions represents the options of a BulkWriter, zero values mean defaults.
+type BulkWriterOptions struct {
+	// MaxDocs is the maximum number of documents in one _bulk_docs request, 500 by default.
+	MaxDocs int
+	// MaxBytes is the maximum size of the documents in one request, 1MB by default.
+	// A single document larger than MaxBytes is sent alone.
+	MaxBytes int
+	// MaxDelay is the longest time a document waits for its batch to fill, 100ms by default.
+	MaxDelay time.Duration
+	// MaxPending is the maximum number of documents added but not written yet,
+	// Add blocks when it is reached. 4 times MaxDocs by default.
+	MaxPending int
+}
+
+// BulkFuture represents the result of a document added to a BulkWriter.
+type BulkFuture struct {
+	done   chan struct{}
+	result UpdateResult
+}
+
+// Done returns a channel closed once the document has been written.
+func (f *BulkFuture) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait blocks until the document has been written and returns its result.
+func (f *BulkFuture) Wait() UpdateResult {
+	<-f.done
+	return f.result
+}
+
+type bulkItem struct {
+	doc      map[string]interface{}
+	data     json.RawMessage
+	future   *BulkFuture
+	callback func(UpdateResult)
+}
+
+// BulkWriter batches documents saved from many goroutines into _bulk_docs requests,
+// which are sent when MaxDocs or MaxBytes is reached or MaxDelay has passed. Requests
+// are sent one at a time, so when the server falls behind pending documents pile up
+// until Add blocks. A BulkWriter must be closed to write the remaining documents.
+type BulkWriter struct {
+	db      *Database
+	options BulkWriterOptions
+
+	mutex  sync.RWMutex
+	closed bool
+	queue  chan *bulkItem
+	slots  chan struct{}
+	done   chan struct{}
+}
+
+// NewBulkWriter returns a newly-created *BulkWriter writing into db.
+func NewBulkWriter(db *Database, options *BulkWriterOptions) *BulkWriter {
+	opts := BulkWriterOptions{}
+	if options != nil {
+		opts = *options
+	}
+	if opts.MaxDocs <= 0 {
+		opts.MaxDocs = defaultBulkMaxDocs
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = defaultBulkMaxBytes
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = defaultBulkMaxDelay
+	}
+	if opts.MaxPending <= 0 {
+		opts.MaxPending = 4 * opts.MaxDocs
+	}
+
+	w := &BulkWriter{
+		db:      db,
+		options: opts,
+		queue:   make(chan *bulkItem, opts.MaxPending),
+		slots:   make(chan struct{}, opts.MaxPending),
+		done:    make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Add queues doc to be saved and returns a future of its result. It blocks while
+// MaxPending documents are waiting to be written. Like with Update the _id and
+// _rev of doc are set once saved, so it must not be used until then.
+func (w *BulkWriter) Add(doc map[string]interface{}) (*BulkFuture, error) {
+	return w.add(doc, nil)
+}
+
+// AddFunc is like Add but calls callback with the result once doc is written.
+// Callbacks are called one at a time from the writing goroutine, they should
+// return quickly and must not add documents to w.
+func (w *BulkWriter) AddFunc(doc map[string]interface{}, callback func(UpdateResult)) error {
+	_, err := w.add(doc, callback)
+	return err
+}
+
+func (w *BulkWriter) add(doc map[string]interface{}, callback func(UpdateResult)) (*BulkFuture, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	item := &bulkItem{
+		doc:      doc,
+		data:     data,
+		future:   &BulkFuture{done: make(chan struct{})},
+		callback: callback,
+	}
+
+	w.slots <- struct{}{}
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+	if w.closed {
+		<-w.slots
+		return nil, ErrBulkWriterClosed
+	}
+	// never blocks as the queue has a slot for every pending document
+	w.queue <- item
+	return item.future, nil
+}
+
+// Close writes the pending documents and stops w, waiting for the last results.
+func (w *BulkWriter) Close() error {
+	w.mutex.Lock()
+	if w.closed {
+		w.mutex.Unlock()
+		return ErrBulkWriterClosed
+	}
+	w.closed = true
+	close(w.queue)
+	w.mutex.Unlock()
+
+	<-w.done
+	return nil
+}
+
+func (w *BulkWriter) run() {
+	defer close(w.done)
+
+	var batch []*bulkItem
+	size := 0
+	timer := time.NewTimer(w.options.MaxDelay)
+	timer.Stop()
+
+	flush := func() {
+		if len(batch) > 0 {
+			w.write(batch)
+		}
+		batch, size = nil, 0
+		timer.Stop()
+	}
+
+	for {
+		select {
+		case item, ok := <-w.queue:
+			if !ok {
+				flush()
+				return
+			}
+			if len(batch) > 0 && size+len(item.data) > w.options.MaxBytes {
+				flush()
+			}
+			if len(batch) == 0 {
+				timer.Reset(w.options.MaxDelay)
+			}
+			batch = append(batch, item)
+			size += len(item.data)
+			if len(batch) >= w.options.MaxDocs || size >= w.options.MaxBytes {
+				flush()
+			}
+		case <-timer.C:
+			flush()
+		}
+	}
+}
+
+// write sends the batch in one _bulk_docs request and delivers the results.
+func (w *BulkWriter) write(batch []*bulkItem) {
+	docs := make([]map[string]interface{}, len(batch))
+	raw := make([]json.RawMessage, len(batch))
+	for i, item := range batch {
+		docs[i], raw[i] = item.doc, item.data
+	}
+
+	var results []UpdateResult
+	_, data, err := w.db.resource.PostJSON("_bulk_docs", nil, map[string]interface{}{"docs": raw}, nil)
+	if err == nil {
+		results, err = parseUpdateResults(docs, data)
+	}
+
+	for i, item := range batch {
+		result := UpdateResult{Err: err}
+		if err == nil && i < len(results) {
+			result = results[i]
+		}
+		if result.ID == "" {
+			result.ID, _ = item.doc["_id"].(string)
+		}
+		item.future.result = result
+		close(item.future.done)
+		if item.callback != nil {
+			item.callback(result)
+		}
+		<-w.slots
+	}
+}
@@ -0,0 +1,55 @@
+package couchdb
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBulkWriter(t *testing.T) {
+	writer := NewBulkWriter(testsDB, &BulkWriterOptions{MaxDocs: 10, MaxDelay: 10 * time.Millisecond, MaxPending: 20})
+
+	var wg sync.WaitGroup
+	futures := make([]*BulkFuture, 50)
+	for i := range futures {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			future, err := writer.Add(map[string]interface{}{"_id": fmt.Sprintf("bulkwriter-%d", i)})
+			if err != nil {
+				t.Error("bulk writer add error", err)
+			}
+			futures[i] = future
+		}(i)
+	}
+	wg.Wait()
+
+	called := make(chan UpdateResult, 1)
+	err := writer.AddFunc(map[string]interface{}{"_id": "bulkwriter-callback"}, func(result UpdateResult) {
+		called <- result
+	})
+	if err != nil {
+		t.Fatal("bulk writer add func error", err)
+	}
+
+	err = writer.Close()
+	if err != nil {
+		t.Fatal("bulk writer close error", err)
+	}
+
+	for i, future := range futures {
+		result := future.Wait()
+		if result.Err != nil || result.ID != fmt.Sprintf("bulkwriter-%d", i) || result.Rev == "" {
+			t.Errorf("bulk writer result %v", result)
+		}
+	}
+	if result := <-called; result.Err != nil || result.ID != "bulkwriter-callback" {
+		t.Errorf("bulk writer callback result %v", result)
+	}
+
+	_, err = writer.Add(map[string]interface{}{"_id": "bulkwriter-closed"})
+	if err != ErrBulkWriterClosed {
+		t.Errorf("bulk writer add error %v want %v", err, ErrBulkWriterClosed)
+	}
+}
@@ -0,0 +1,165 @@
+package couchdb
+
+import (
+	"container/list"
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// defaultCacheEntries is the number of responses an ETagCache keeps by default
+const defaultCacheEntries = 1000
+
+// ETagCache keeps the ETags and bodies of GET responses, such as documents, views
+// and _all_docs, so that they are revalidated with If-None-Match and served from
+// the cache when the server answers 304 Not Modified. The least recently used
+// responses are evicted once it is full. It is safe for concurrent use.
+type ETagCache struct {
+	maxEntries int
+
+	mutex   sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+	hits    int
+	misses  int
+}
+
+type cacheEntry struct {
+	key    string
+	etag   string
+	header http.Header
+	data   []byte
+}
+
+// NewETagCache returns a newly-created *ETagCache holding up to maxEntries
+// responses, 1000 by default if not positive.
+func NewETagCache(maxEntries int) *ETagCache {
+	if maxEntries <= 0 {
+		maxEntries = defaultCacheEntries
+	}
+	return &ETagCache{
+		maxEntries: maxEntries,
+		entries:    map[string]*list.Element{},
+		order:      list.New(),
+	}
+}
+
+// Len returns the number of responses cached.
+func (c *ETagCache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.order.Len()
+}
+
+// Stats returns the number of responses served from the cache and the number
+// of those downloaded.
+func (c *ETagCache) Stats() (hits, misses int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.hits, c.misses
+}
+
+// Clear removes all the responses cached.
+func (c *ETagCache) Clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries = map[string]*list.Element{}
+	c.order.Init()
+}
+
+func (c *ETagCache) get(key string) *cacheEntry {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*cacheEntry)
+}
+
+func (c *ETagCache) put(entry *cacheEntry) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.misses++
+	if elem, ok := c.entries[entry.key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[entry.key] = c.order.PushFront(entry)
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+func (c *ETagCache) hit() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.hits++
+}
+
+// SetCache makes the resource, and the ones created from it afterwards, cache
+// their GET responses in cache, nil disables caching.
+func (r *Resource) SetCache(cache *ETagCache) {
+	r.cache = cache
+}
+
+// SetCache makes the database cache the GET responses of documents, views and
+// _all_docs in cache and revalidate them with their ETags, nil disables caching.
+// Responses are shared with the cache, they must not be modified.
+func (d *Database) SetCache(cache *ETagCache) {
+	d.resource.SetCache(cache)
+}
+
+// cachedGet issues a GET revalidating the response cached for the same URL, if any.
+// Responses are cached by URL without its userinfo, a cache must not be shared by
+// resources whose users may see different documents.
+func (r *Resource) cachedGet(ctx context.Context, u *url.URL, header http.Header, params url.Values) (http.Header, []byte, error) {
+	if r.cache == nil || header.Get("If-None-Match") != "" {
+		return requestContext(ctx, http.MethodGet, u, header, nil, params)
+	}
+
+	// the credentials are left out of the key so that they are not kept in memory
+	reqURL := *u
+	reqURL.User = nil
+	reqURL.RawQuery = params.Encode()
+	key := reqURL.String() + " " + header.Get("Accept")
+
+	reqHeader := http.Header{}
+	for k, v := range header {
+		reqHeader[k] = v
+	}
+	entry := r.cache.get(key)
+	if entry != nil {
+		reqHeader.Set("If-None-Match", entry.etag)
+	}
+
+	rspHeader, data, err := requestContext(ctx, http.MethodGet, u, reqHeader, nil, params)
+	if err == ErrNotModified && entry != nil {
+		r.cache.hit()
+		return entry.header, entry.data, nil
+	}
+	if err != nil {
+		return rspHeader, data, err
+	}
+
+	if etag := rspHeader.Get("ETag"); etag != "" {
+		r.cache.put(&cacheEntry{key: key, etag: etag, header: rspHeader, data: data})
+	}
+	return rspHeader, data, nil
+}
+
+// Rev returns the current revision of the document with the specified ID from
+// the ETag of a HEAD request, without fetching its body.
+func (d *Database) Rev(docid string) (string, error) {
+	header, _, err := docResource(d.resource, docid).Head("", nil, nil)
+	if err != nil {
+		return "", err
+	}
+	return strings.Trim(header.Get("ETag"), `"`), nil
+}
@@ -0,0 +1,67 @@
+package couchdb
+
+import (
+	"testing"
+)
+
+func TestETagCache(t *testing.T) {
+	db, err := NewDatabase(testsDB.resource.base.String())
+	if err != nil {
+		t.Fatal("new database error", err)
+	}
+	cache := NewETagCache(10)
+	db.SetCache(cache)
+
+	doc := map[string]interface{}{"_id": "cached", "value": 1}
+	_, rev, err := db.Save(doc, nil)
+	if err != nil {
+		t.Fatal("db save error", err)
+	}
+
+	current, err := db.Rev("cached")
+	if err != nil {
+		t.Fatal("db rev error", err)
+	}
+	if current != rev {
+		t.Errorf("db rev %s want %s", current, rev)
+	}
+
+	for i := 0; i < 3; i++ {
+		got, err := db.Get("cached", nil)
+		if err != nil {
+			t.Fatal("db get error", err)
+		}
+		if got["_rev"] != rev {
+			t.Errorf("cached doc rev %v want %s", got["_rev"], rev)
+		}
+	}
+	if hits, misses := cache.Stats(); hits != 2 || misses != 1 {
+		t.Errorf("cache hits %d misses %d want 2 and 1", hits, misses)
+	}
+
+	doc["value"] = 2
+	_, rev, err = db.Save(doc, nil)
+	if err != nil {
+		t.Fatal("db save error", err)
+	}
+	got, err := db.Get("cached", nil)
+	if err != nil {
+		t.Fatal("db get error", err)
+	}
+	if got["_rev"] != rev {
+		t.Errorf("revalidated doc rev %v want %s", got["_rev"], rev)
+	}
+}
+
+func TestETagCacheEviction(t *testing.T) {
+	cache := NewETagCache(2)
+	for _, key := range []string{"a", "b", "c"} {
+		cache.put(&cacheEntry{key: key, etag: key})
+	}
+	if cache.Len() != 2 {
+		t.Errorf("cache len %d want 2", cache.Len())
+	}
+	if cache.get("a") != nil || cache.get("c") == nil {
+		t.Error("cache did not evict the least recently used entry")
+	}
+}
@@ -0,0 +1,205 @@
+package couchdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// States of the cluster setup wizard reported by _cluster_setup.
+const (
+	ClusterDisabled    = "cluster_disabled"
+	ClusterEnabled     = "cluster_enabled"
+	ClusterFinished    = "cluster_finished"
+	SingleNodeDisabled = "single_node_disabled"
+	SingleNodeEnabled  = "single_node_enabled"
+)
+
+const defaultClusterPort = 5984
+
+// ClusterNode represents a node to join into the cluster.
+// Username and Password are the credentials currently valid on that node,
+// leave them empty if they are the same as the ones of the cluster.
+type ClusterNode struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+}
+
+// ClusterSetup describes a cluster to be set up with Server.SetupCluster.
+type ClusterSetup struct {
+	// Username and Password of the server admin created on every node.
+	Username string
+	Password string
+	// BindAddress the nodes listen on, "0.0.0.0" by default.
+	BindAddress string
+	// Port the nodes listen on, 5984 by default.
+	Port int
+	// Nodes to add to the cluster, not including the node Server talks to.
+	Nodes []ClusterNode
+	// EnsureDBs are the system databases created when finishing the cluster,
+	// "_users", "_replicator" and "_global_changes" if empty.
+	EnsureDBs []string
+}
+
+func (cs *ClusterSetup) bindAddress() string {
+	if cs.BindAddress == "" {
+		return "0.0.0.0"
+	}
+	return cs.BindAddress
+}
+
+func (cs *ClusterSetup) port() int {
+	if cs.Port <= 0 {
+		return defaultClusterPort
+	}
+	return cs.Port
+}
+
+func (cn ClusterNode) port() int {
+	if cn.Port <= 0 {
+		return defaultClusterPort
+	}
+	return cn.Port
+}
+
+// ClusterSetupState returns the state of the cluster setup, one of ClusterDisabled,
+// ClusterEnabled, ClusterFinished, SingleNodeDisabled and SingleNodeEnabled.
+// ensureDBs: optional databases which must exist for the cluster to be finished.
+func (s *Server) ClusterSetupState(ensureDBs []string) (string, error) {
+	params := url.Values{}
+	if len(ensureDBs) > 0 {
+		dbs, err := json.Marshal(ensureDBs)
+		if err != nil {
+			return "", err
+		}
+		params.Set("ensure_dbs_exist", string(dbs))
+	}
+
+	_, data, err := s.resource.GetJSON("_cluster_setup", nil, params)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := parseData(data)
+	if err != nil {
+		return "", err
+	}
+	state, _ := result["state"].(string)
+	return state, nil
+}
+
+// EnableCluster runs the enable_cluster action of the cluster setup. If node is nil
+// the node Server talks to is enabled, otherwise it is enabled remotely through it.
+func (s *Server) EnableCluster(setup *ClusterSetup, node *ClusterNode) error {
+	body := map[string]interface{}{
+		"action":       "enable_cluster",
+		"bind_address": setup.bindAddress(),
+		"port":         setup.port(),
+		"username":     setup.Username,
+		"password":     setup.Password,
+		"node_count":   len(setup.Nodes) + 1,
+	}
+
+	if node != nil {
+		body["remote_node"] = node.Host
+		body["port"] = node.port()
+		body["remote_current_user"] = setup.Username
+		body["remote_current_password"] = setup.Password
+		if node.Username != "" {
+			body["remote_current_user"] = node.Username
+			body["remote_current_password"] = node.Password
+		}
+	}
+
+	return s.clusterSetupAction(body)
+}
+
+// AddClusterNode runs the add_node action of the cluster setup, which joins node into the cluster.
+func (s *Server) AddClusterNode(setup *ClusterSetup, node ClusterNode) error {
+	body := map[string]interface{}{
+		"action":   "add_node",
+		"host":     node.Host,
+		"port":     node.port(),
+		"username": setup.Username,
+		"password": setup.Password,
+	}
+	return s.clusterSetupAction(body)
+}
+
+// FinishCluster runs the finish_cluster action of the cluster setup,
+// which creates the system databases.
+func (s *Server) FinishCluster(ensureDBs []string) error {
+	body := map[string]interface{}{
+		"action": "finish_cluster",
+	}
+	if len(ensureDBs) > 0 {
+		body["ensure_dbs_exist"] = ensureDBs
+	}
+	return s.clusterSetupAction(body)
+}
+
+func (s *Server) clusterSetupAction(body map[string]interface{}) error {
+	_, data, err := s.resource.PostJSON("_cluster_setup", nil, body, nil)
+	if err != nil {
+		return err
+	}
+	_, err = parseData(data)
+	return err
+}
+
+// SetupCluster drives the whole cluster setup workflow: enables the cluster on
+// the node Server talks to and on every node in setup, adds them into the cluster
+// and finishes it, then verifies the result through Membership.
+// Once the cluster is enabled every request needs the admin credentials, so
+// the URL of Server must contain setup.Username and setup.Password.
+func (s *Server) SetupCluster(setup *ClusterSetup) error {
+	state, err := s.ClusterSetupState(setup.EnsureDBs)
+	if err != nil {
+		return err
+	}
+
+	if state != ClusterFinished {
+		if state != ClusterEnabled {
+			err = s.EnableCluster(setup, nil)
+			if err != nil {
+				return fmt.Errorf("enable cluster on coordinator: %v", err)
+			}
+		}
+
+		for i := range setup.Nodes {
+			node := setup.Nodes[i]
+			err = s.EnableCluster(setup, &node)
+			if err != nil {
+				return fmt.Errorf("enable cluster on %s: %v", node.Host, err)
+			}
+			err = s.AddClusterNode(setup, node)
+			if err != nil {
+				return fmt.Errorf("add node %s: %v", node.Host, err)
+			}
+		}
+
+		err = s.FinishCluster(setup.EnsureDBs)
+		if err != nil {
+			return fmt.Errorf("finish cluster: %v", err)
+		}
+
+		state, err = s.ClusterSetupState(setup.EnsureDBs)
+		if err != nil {
+			return err
+		}
+		if state != ClusterFinished {
+			return fmt.Errorf("cluster setup state %s want %s", state, ClusterFinished)
+		}
+	}
+
+	_, clusterNodes, err := s.Membership()
+	if err != nil {
+		return err
+	}
+	if len(clusterNodes) < len(setup.Nodes)+1 {
+		return fmt.Errorf("cluster has %d nodes %v want %d", len(clusterNodes), clusterNodes, len(setup.Nodes)+1)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package couchdb
+
+import (
+	"testing"
+)
+
+func TestClusterSetupState(t *testing.T) {
+	state, err := server.ClusterSetupState(nil)
+	if err != nil {
+		t.Fatal("server cluster setup state error", err)
+	}
+
+	switch state {
+	case ClusterDisabled, ClusterEnabled, ClusterFinished, SingleNodeDisabled, SingleNodeEnabled:
+	default:
+		t.Errorf("unknown cluster setup state %s", state)
+	}
+}
+
+func TestClusterSetupDefaults(t *testing.T) {
+	setup := &ClusterSetup{
+		Nodes: []ClusterNode{{Host: "10.0.0.2"}, {Host: "10.0.0.3", Port: 15984}},
+	}
+
+	if setup.bindAddress() != "0.0.0.0" {
+		t.Errorf("bind address %s want 0.0.0.0", setup.bindAddress())
+	}
+
+	if setup.port() != 5984 {
+		t.Errorf("port %d want 5984", setup.port())
+	}
+
+	if setup.Nodes[0].port() != 5984 || setup.Nodes[1].port() != 15984 {
+		t.Errorf("node ports %d %d want 5984 15984", setup.Nodes[0].port(), setup.Nodes[1].port())
+	}
+}
@@ -0,0 +1,289 @@
+package couchdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// compactionStartGrace is how long CompactAndWait waits for compaction to start
+const compactionStartGrace = 10 * time.Second
+
+var (
+	// errCompactionRunning is returned by the probes of compaction still running
+	errCompactionRunning = errors.New("compaction running")
+	// errCompactionPending is returned by the probes of compaction not started yet
+	errCompactionPending = errors.New("compaction pending")
+)
+
+// shardName matches the name of a shard file, e.g. shards/00000000-1fffffff/db.1525663362
+var shardName = regexp.MustCompile(`^shards/[0-9a-f]+-[0-9a-f]+/(.+)\.[0-9]+$`)
+
+// Types of the compaction tasks in _active_tasks.
+const (
+	DatabaseCompaction = "database_compaction"
+	ViewCompaction     = "view_compaction"
+)
+
+// CompactionTask represents a compaction task listed in _active_tasks. On CouchDB
+// 2.0 and later there is one task per shard of the database.
+type CompactionTask struct {
+	Type           string `json:"type"`
+	Node           string `json:"node"`
+	Database       string `json:"database"`
+	DesignDocument string `json:"design_document"`
+	Phase          string `json:"phase"`
+	Progress       int    `json:"progress"`
+	ChangesDone    int64  `json:"changes_done"`
+	TotalChanges   int64  `json:"total_changes"`
+	StartedOn      int64  `json:"started_on"`
+	UpdatedOn      int64  `json:"updated_on"`
+}
+
+// taskDatabase returns the name of the database a task runs on, without the shard part.
+func taskDatabase(name string) string {
+	if m := shardName.FindStringSubmatch(name); m != nil {
+		return m[1]
+	}
+	return name
+}
+
+// CompactView compacts the view indexes of the design document, which may be
+// given with or without the _design/ prefix.
+func (d *Database) CompactView(ddoc string) error {
+	_, _, err := d.resource.PostJSON("_compact/"+strings.TrimPrefix(ddoc, "_design/"), nil, nil, nil)
+	return err
+}
+
+// CompactionTasks returns the compaction tasks running on the database and its views.
+func (d *Database) CompactionTasks() ([]CompactionTask, error) {
+	name, err := d.Name()
+	if err != nil {
+		return nil, err
+	}
+	root, err := d.resource.NewResourceWithURL("..")
+	if err != nil {
+		return nil, err
+	}
+	_, data, err := root.GetJSON("_active_tasks", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []CompactionTask
+	err = json.Unmarshal(data, &tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []CompactionTask{}
+	for _, task := range tasks {
+		if (task.Type == DatabaseCompaction || task.Type == ViewCompaction) && taskDatabase(task.Database) == name {
+			result = append(result, task)
+		}
+	}
+	return result, nil
+}
+
+// CompactAndWait compacts the database and waits until the compaction has finished
+// on all its shards, checking _active_tasks and the database information every
+// interval, until ctx is done. As compaction starts asynchronously, it first waits
+// up to compactionStartGrace for it to be seen running or to change the file size.
+func (d *Database) CompactAndWait(ctx context.Context, interval time.Duration) error {
+	state := func() (bool, int64, error) {
+		info, err := d.DatabaseInfo()
+		if err != nil {
+			return false, 0, err
+		}
+		return info.CompactRunning, info.Sizes.File, nil
+	}
+	_, size, err := state()
+	if err != nil {
+		return err
+	}
+	err = d.Compact()
+	if err != nil {
+		return err
+	}
+	return d.waitCompaction(ctx, interval, size, state, func(task CompactionTask) bool {
+		return task.Type == DatabaseCompaction
+	})
+}
+
+// CompactViewAndWait compacts the view indexes of the design document and waits
+// until the compaction has finished like CompactAndWait.
+func (d *Database) CompactViewAndWait(ctx context.Context, ddoc string, interval time.Duration) error {
+	ddoc = strings.TrimPrefix(ddoc, "_design/")
+	state := func() (bool, int64, error) {
+		info, err := d.DesignDocInfo(ddoc)
+		if err != nil {
+			return false, 0, err
+		}
+		return info.ViewIndex.CompactRunning, info.ViewIndex.Sizes.File, nil
+	}
+	_, size, err := state()
+	if err != nil {
+		return err
+	}
+	err = d.CompactView(ddoc)
+	if err != nil {
+		return err
+	}
+	return d.waitCompaction(ctx, interval, size, state, func(task CompactionTask) bool {
+		return task.Type == ViewCompaction && strings.TrimPrefix(task.DesignDocument, "_design/") == ddoc
+	})
+}
+
+// waitCompaction waits for the compaction to start, that is until it is seen
+// running or the file size differs from size, for at most compactionStartGrace,
+// then until state reports it is not running and no task matches. A failed
+// request stops the wait.
+func (d *Database) waitCompaction(ctx context.Context, interval time.Duration, size int64, state func() (bool, int64, error), match func(CompactionTask) bool) error {
+	var failed error
+	graceCtx, cancel := context.WithTimeout(ctx, compactionStartGrace)
+	defer cancel()
+	err := poll(graceCtx, interval, func() error {
+		running, current, err := d.compactionState(state, match)
+		if err != nil {
+			failed = err
+			return nil
+		}
+		if !running && current == size {
+			return errCompactionPending
+		}
+		return nil
+	})
+	if failed != nil {
+		return failed
+	}
+	if ctx.Err() != nil {
+		return err
+	}
+
+	err = poll(ctx, interval, func() error {
+		running, _, err := d.compactionState(state, match)
+		if err != nil {
+			failed = err
+			return nil
+		}
+		if running {
+			return errCompactionRunning
+		}
+		return nil
+	})
+	if failed != nil {
+		return failed
+	}
+	return err
+}
+
+// compactionState returns whether the compaction is running, according to state
+// or to the tasks matching, along with the file size reported by state.
+func (d *Database) compactionState(state func() (bool, int64, error), match func(CompactionTask) bool) (bool, int64, error) {
+	running, size, err := state()
+	if err != nil || running {
+		return running, size, err
+	}
+	tasks, err := d.CompactionTasks()
+	if err != nil {
+		return false, size, err
+	}
+	for _, task := range tasks {
+		if match(task) {
+			return true, size, nil
+		}
+	}
+	return false, size, nil
+}
+
+// CompactionPolicy represents when AutoCompact compacts databases and views.
+type CompactionPolicy struct {
+	// DatabaseThreshold is the fragmentation from 0 to 1 above which databases
+	// are compacted, databases are not compacted if zero.
+	DatabaseThreshold float64
+	// ViewThreshold is the fragmentation from 0 to 1 above which view indexes
+	// are compacted, views are not compacted if zero.
+	ViewThreshold float64
+	// MinFileSize is the file size in bytes below which nothing is compacted.
+	MinFileSize int64
+	// Interval is how often the progress of compaction is checked, 1s by default.
+	Interval time.Duration
+}
+
+// Compaction represents a compaction done by AutoCompact, DesignDoc is empty
+// for the compaction of the database.
+type Compaction struct {
+	Database      string
+	DesignDoc     string
+	Fragmentation float64
+}
+
+// AutoCompact compacts the database and the view indexes of its design documents
+// whose fragmentation exceeds the thresholds of policy, one at a time waiting for
+// each compaction to finish. Returns the compactions done.
+func (d *Database) AutoCompact(ctx context.Context, policy CompactionPolicy) ([]Compaction, error) {
+	done := []Compaction{}
+	info, err := d.DatabaseInfo()
+	if err != nil {
+		return done, err
+	}
+
+	if policy.DatabaseThreshold > 0 && info.Sizes.File >= policy.MinFileSize && info.Fragmentation() > policy.DatabaseThreshold {
+		err = d.CompactAndWait(ctx, policy.Interval)
+		if err != nil {
+			return done, err
+		}
+		done = append(done, Compaction{Database: info.DBName, Fragmentation: info.Fragmentation()})
+	}
+
+	if policy.ViewThreshold <= 0 {
+		return done, nil
+	}
+	ddocs, err := d.DesignDocs(nil)
+	if err != nil {
+		return done, err
+	}
+	for _, row := range ddocs.Rows {
+		ddoc := strings.TrimPrefix(row.ID, "_design/")
+		viewInfo, err := d.DesignDocInfo(ddoc)
+		if err != nil {
+			return done, err
+		}
+		index := viewInfo.ViewIndex
+		if index.Sizes.File < policy.MinFileSize || index.Fragmentation() <= policy.ViewThreshold {
+			continue
+		}
+		err = d.CompactViewAndWait(ctx, ddoc, policy.Interval)
+		if err != nil {
+			return done, err
+		}
+		done = append(done, Compaction{Database: info.DBName, DesignDoc: ddoc, Fragmentation: index.Fragmentation()})
+	}
+	return done, nil
+}
+
+// AutoCompact applies the compaction policy to all the databases of the server
+// like Database.AutoCompact. Returns the compactions done.
+func (s *Server) AutoCompact(ctx context.Context, policy CompactionPolicy) ([]Compaction, error) {
+	done := []Compaction{}
+	names, err := s.DBs()
+	if err != nil {
+		return done, err
+	}
+	for _, name := range names {
+		db, err := s.Get(name)
+		if err != nil {
+			return done, fmt.Errorf("database %s: %v", name, err)
+		}
+		compactions, err := db.AutoCompact(ctx, policy)
+		done = append(done, compactions...)
+		if err != nil {
+			return done, fmt.Errorf("database %s: %v", name, err)
+		}
+	}
+	return done, nil
+}
@@ -0,0 +1,100 @@
+package couchdb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompactAndWait(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	err := testsDB.CompactAndWait(ctx, 100*time.Millisecond)
+	if err != nil {
+		t.Fatal("db compact and wait error", err)
+	}
+	info, err := testsDB.DatabaseInfo()
+	if err != nil {
+		t.Fatal("db database info error", err)
+	}
+	if info.CompactRunning {
+		t.Error("db compaction still running")
+	}
+
+	tasks, err := testsDB.CompactionTasks()
+	if err != nil {
+		t.Fatal("db compaction tasks error", err)
+	}
+	for _, task := range tasks {
+		if task.Type == DatabaseCompaction {
+			t.Errorf("db compaction task %+v still running", task)
+		}
+	}
+}
+
+func TestAutoCompact(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// nothing is compacted with thresholds out of reach
+	done, err := testsDB.AutoCompact(ctx, CompactionPolicy{DatabaseThreshold: 1, ViewThreshold: 1})
+	if err != nil {
+		t.Fatal("db auto compact error", err)
+	}
+	if len(done) != 0 {
+		t.Errorf("auto compactions %v want none", done)
+	}
+}
+
+func TestTaskDatabase(t *testing.T) {
+	tests := map[string]string{
+		"golang-tests": "golang-tests",
+		"shards/00000000-1fffffff/golang-tests.1525663362": "golang-tests",
+		"shards/e0000000-ffffffff/a.b.1525663362":          "a.b",
+	}
+	for name, want := range tests {
+		if got := taskDatabase(name); got != want {
+			t.Errorf("task database of %s = %s want %s", name, got, want)
+		}
+	}
+}
+
+func TestWaitCompactionStart(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "_active_tasks") {
+			fmt.Fprint(w, `[]`)
+			return
+		}
+		fmt.Fprint(w, `{"db_name":"compact"}`)
+	}))
+	defer ts.Close()
+
+	db, err := NewDatabase(ts.URL + "/compact")
+	if err != nil {
+		t.Fatal("new database error", err)
+	}
+
+	// the compaction starts on the third check and finishes on the fifth
+	checks := 0
+	state := func() (bool, int64, error) {
+		checks++
+		if checks >= 5 {
+			return false, 50, nil
+		}
+		return checks >= 3, 100, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = db.waitCompaction(ctx, time.Millisecond, 100, state, func(CompactionTask) bool { return false })
+	if err != nil {
+		t.Fatal("wait compaction error", err)
+	}
+	if checks != 5 {
+		t.Errorf("wait compaction checks %d want 5", checks)
+	}
+}
@@ -0,0 +1,351 @@
+package couchdb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// ConflictsDesignDoc is the name of the design document holding the built-in
+// view which Database.Conflicts uses to find conflicted documents.
+const ConflictsDesignDoc = "couchdb-golang-conflicts"
+
+const conflictsMapFunc = `function(doc) {
+  if (doc._conflicts) {
+    emit(doc._id, {"rev": doc._rev, "conflicts": doc._conflicts});
+  }
+}`
+
+// ErrNoCommonAncestor for conflicting revisions whose common ancestor is unavailable.
+var ErrNoCommonAncestor = errors.New("common ancestor of revisions not available")
+
+// ConflictedDoc represents a document with conflicting revisions.
+type ConflictedDoc struct {
+	ID        string
+	Rev       string
+	Conflicts []string
+}
+
+// Conflicts returns all the documents with conflicting revisions. It syncs the
+// built-in view in design document ConflictsDesignDoc into the database on first use.
+func (d *Database) Conflicts() ([]ConflictedDoc, error) {
+	defn, err := NewViewDefinition(ConflictsDesignDoc, "conflicts", conflictsMapFunc, "", "", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = defn.Sync(d)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := defn.View(d, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := results.Rows()
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]ConflictedDoc, 0, len(rows))
+	for _, row := range rows {
+		data, err := json.Marshal(row.Val)
+		if err != nil {
+			return nil, err
+		}
+		var val struct {
+			Rev       string   `json:"rev"`
+			Conflicts []string `json:"conflicts"`
+		}
+		err = json.Unmarshal(data, &val)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, ConflictedDoc{ID: row.ID, Rev: val.Rev, Conflicts: val.Conflicts})
+	}
+	return docs, nil
+}
+
+// openRevs fetches the given revisions of the document in one request, revs
+// is either "all" for all the leaf revisions or a JSON array of revisions.
+// It returns the documents found and the revisions missing.
+func (d *Database) openRevs(docid, revs string, options url.Values) ([]map[string]interface{}, []string, error) {
+	params := url.Values{}
+	for k, v := range options {
+		params[k] = v
+	}
+	params.Set("open_revs", revs)
+
+	header := http.Header{}
+	header.Set("Accept", "application/json")
+
+	_, data, err := docResource(d.resource, docid).GetJSON("", header, params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var results []struct {
+		OK      map[string]interface{} `json:"ok"`
+		Missing string                 `json:"missing"`
+	}
+	err = json.Unmarshal(data, &results)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	docs := []map[string]interface{}{}
+	missing := []string{}
+	for _, result := range results {
+		if result.OK != nil {
+			docs = append(docs, result.OK)
+		} else if result.Missing != "" {
+			missing = append(missing, result.Missing)
+		}
+	}
+	return docs, missing, nil
+}
+
+// LeafRevisions returns all the leaf revisions of the document, including
+// deleted ones, each one with its _revisions history.
+func (d *Database) LeafRevisions(docid string) ([]map[string]interface{}, error) {
+	docs, _, err := d.openRevs(docid, "all", url.Values{"revs": []string{"true"}})
+	return docs, err
+}
+
+// revisionPath returns the revisions from doc back to the oldest one known,
+// based on its _revisions history.
+func revisionPath(doc map[string]interface{}) []string {
+	revisions, ok := doc["_revisions"].(map[string]interface{})
+	if !ok {
+		if rev, ok := doc["_rev"].(string); ok {
+			return []string{rev}
+		}
+		return nil
+	}
+	start, _ := revisions["start"].(float64)
+	ids, _ := revisions["ids"].([]interface{})
+	path := make([]string, 0, len(ids))
+	for i, id := range ids {
+		path = append(path, fmt.Sprintf("%d-%v", int(start)-i, id))
+	}
+	return path
+}
+
+// commonAncestor returns the latest revision shared by the histories of all the
+// leaves, or an empty string if there is none.
+func commonAncestor(leaves []map[string]interface{}) string {
+	if len(leaves) == 0 {
+		return ""
+	}
+
+	shared := map[string]int{}
+	for _, leaf := range leaves {
+		for _, rev := range revisionPath(leaf) {
+			shared[rev]++
+		}
+	}
+
+	for _, rev := range revisionPath(leaves[0]) {
+		if shared[rev] == len(leaves) {
+			return rev
+		}
+	}
+	return ""
+}
+
+// Resolver decides the content of a document with conflicting revisions.
+// leaves are the non-deleted leaf revisions with the current winner first, and
+// ancestor is the body of their common ancestor, nil if not available.
+type Resolver interface {
+	Resolve(docid string, leaves []map[string]interface{}, ancestor map[string]interface{}) (map[string]interface{}, error)
+}
+
+// ResolverFunc is an adapter allowing a custom merge function to be used as a Resolver.
+type ResolverFunc func(docid string, leaves []map[string]interface{}, ancestor map[string]interface{}) (map[string]interface{}, error)
+
+// Resolve calls f(docid, leaves, ancestor).
+func (f ResolverFunc) Resolve(docid string, leaves []map[string]interface{}, ancestor map[string]interface{}) (map[string]interface{}, error) {
+	return f(docid, leaves, ancestor)
+}
+
+// LastWriteWins returns a Resolver picking the leaf with the greatest value of field,
+// e.g. a timestamp of the last update. Numbers and strings are compared, leaves
+// without field lose, the current winner is kept on ties.
+func LastWriteWins(field string) Resolver {
+	return ResolverFunc(func(docid string, leaves []map[string]interface{}, ancestor map[string]interface{}) (map[string]interface{}, error) {
+		if len(leaves) == 0 {
+			return nil, fmt.Errorf("no revisions of %s to resolve", docid)
+		}
+		latest := leaves[0]
+		for _, leaf := range leaves[1:] {
+			if greaterValue(leaf[field], latest[field]) {
+				latest = leaf
+			}
+		}
+		return latest, nil
+	})
+}
+
+// greaterValue returns true if a is greater than b, any value is greater than nil.
+func greaterValue(a, b interface{}) bool {
+	switch a := a.(type) {
+	case float64:
+		bf, ok := b.(float64)
+		return !ok || a > bf
+	case string:
+		bs, ok := b.(string)
+		return !ok || a > bs
+	case nil:
+		return false
+	default:
+		return b == nil
+	}
+}
+
+// ThreeWayMerge returns a Resolver merging the changes each leaf made to
+// their common ancestor into the current winner. Nested objects are merged
+// recursively, when leaves changed the same field differently the winner's
+// value is kept. It fails with ErrNoCommonAncestor if the ancestor is not available.
+func ThreeWayMerge() Resolver {
+	return ResolverFunc(func(docid string, leaves []map[string]interface{}, ancestor map[string]interface{}) (map[string]interface{}, error) {
+		if len(leaves) == 0 {
+			return nil, fmt.Errorf("no revisions of %s to resolve", docid)
+		}
+		if ancestor == nil {
+			return nil, ErrNoCommonAncestor
+		}
+		merged := leaves[0]
+		for _, leaf := range leaves[1:] {
+			merged = merge3(ancestor, merged, leaf)
+		}
+		return merged, nil
+	})
+}
+
+// merge3 applies the changes theirs made to base onto ours.
+func merge3(base, ours, theirs map[string]interface{}) map[string]interface{} {
+	merged := map[string]interface{}{}
+	for k, v := range ours {
+		merged[k] = v
+	}
+
+	keys := map[string]bool{}
+	for k := range base {
+		keys[k] = true
+	}
+	for k := range theirs {
+		keys[k] = true
+	}
+
+	for k := range keys {
+		if strings.HasPrefix(k, "_") {
+			continue
+		}
+		baseVal, inBase := base[k]
+		theirVal, inTheirs := theirs[k]
+		ourVal, inOurs := ours[k]
+
+		if inBase == inTheirs && reflect.DeepEqual(baseVal, theirVal) {
+			// unchanged by theirs
+			continue
+		}
+
+		if inBase == inOurs && reflect.DeepEqual(baseVal, ourVal) {
+			// changed by theirs only
+			if inTheirs {
+				merged[k] = theirVal
+			} else {
+				delete(merged, k)
+			}
+			continue
+		}
+
+		// changed by both, merge objects recursively, otherwise ours wins
+		baseObj, baseOK := baseVal.(map[string]interface{})
+		ourObj, ourOK := ourVal.(map[string]interface{})
+		theirObj, theirOK := theirVal.(map[string]interface{})
+		if ourOK && theirOK {
+			if !baseOK {
+				baseObj = map[string]interface{}{}
+			}
+			merged[k] = merge3(baseObj, ourObj, theirObj)
+		}
+	}
+	return merged
+}
+
+// Resolve resolves the conflicting revisions of the document with resolver.
+// The document returned by resolver is written as a new revision on top of
+// the current winner and all the other leaves are deleted, in a single bulk update.
+// It returns nil results if the document has no conflicts.
+func (d *Database) Resolve(docid string, resolver Resolver) ([]UpdateResult, error) {
+	current, err := d.Get(docid, nil)
+	if err != nil {
+		return nil, err
+	}
+	winnerRev, _ := current["_rev"].(string)
+
+	leaves, err := d.LeafRevisions(docid)
+	if err != nil {
+		return nil, err
+	}
+
+	// put the current winner first, skip the deleted leaves
+	live := []map[string]interface{}{}
+	for _, leaf := range leaves {
+		if deleted, _ := leaf["_deleted"].(bool); deleted {
+			continue
+		}
+		if leaf["_rev"] == winnerRev {
+			live = append([]map[string]interface{}{leaf}, live...)
+		} else {
+			live = append(live, leaf)
+		}
+	}
+
+	if len(live) <= 1 {
+		return nil, nil
+	}
+
+	var ancestor map[string]interface{}
+	if rev := commonAncestor(live); rev != "" {
+		docs, _, err := d.openRevs(docid, fmt.Sprintf(`["%s"]`, rev), nil)
+		if err == nil && len(docs) == 1 {
+			ancestor = docs[0]
+		}
+	}
+
+	for _, leaf := range live {
+		delete(leaf, "_revisions")
+	}
+
+	merged, err := resolver.Resolve(docid, live, ancestor)
+	if err != nil {
+		return nil, err
+	}
+
+	winner := map[string]interface{}{}
+	for k, v := range merged {
+		winner[k] = v
+	}
+	winner["_id"] = docid
+	winner["_rev"] = winnerRev
+	delete(winner, "_conflicts")
+	delete(winner, "_revisions")
+
+	docs := []map[string]interface{}{winner}
+	for _, leaf := range live[1:] {
+		docs = append(docs, map[string]interface{}{
+			"_id":      docid,
+			"_rev":     leaf["_rev"],
+			"_deleted": true,
+		})
+	}
+	return d.Update(docs, nil)
+}
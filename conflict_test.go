@@ -0,0 +1,128 @@
+package couchdb
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCommonAncestor(t *testing.T) {
+	leaves := []map[string]interface{}{
+		{"_rev": "3-c", "_revisions": map[string]interface{}{"start": float64(3), "ids": []interface{}{"c", "b", "a"}}},
+		{"_rev": "3-d", "_revisions": map[string]interface{}{"start": float64(3), "ids": []interface{}{"d", "b", "a"}}},
+		{"_rev": "2-e", "_revisions": map[string]interface{}{"start": float64(2), "ids": []interface{}{"e", "a"}}},
+	}
+	if rev := commonAncestor(leaves); rev != "1-a" {
+		t.Errorf("common ancestor %s want 1-a", rev)
+	}
+	if rev := commonAncestor(leaves[:2]); rev != "2-b" {
+		t.Errorf("common ancestor %s want 2-b", rev)
+	}
+}
+
+func TestLastWriteWins(t *testing.T) {
+	leaves := []map[string]interface{}{
+		{"_rev": "2-a", "updated": "2017-01-01"},
+		{"_rev": "2-b", "updated": "2017-03-01"},
+		{"_rev": "2-c"},
+	}
+	winner, err := LastWriteWins("updated").Resolve("doc", leaves, nil)
+	if err != nil {
+		t.Fatal("resolve error", err)
+	}
+	if winner["_rev"] != "2-b" {
+		t.Errorf("winner %v want 2-b", winner["_rev"])
+	}
+}
+
+func TestMerge3(t *testing.T) {
+	base := map[string]interface{}{
+		"name":    "Tom",
+		"age":     float64(30),
+		"removed": true,
+		"address": map[string]interface{}{"city": "Chengdu", "zip": "610000"},
+	}
+	ours := map[string]interface{}{
+		"name":    "Tommy",
+		"age":     float64(30),
+		"removed": true,
+		"address": map[string]interface{}{"city": "Beijing", "zip": "610000"},
+	}
+	theirs := map[string]interface{}{
+		"name":    "Thomas",
+		"age":     float64(31),
+		"added":   "yes",
+		"address": map[string]interface{}{"city": "Chengdu", "zip": "100000"},
+	}
+	want := map[string]interface{}{
+		"name":    "Tommy",
+		"age":     float64(31),
+		"added":   "yes",
+		"address": map[string]interface{}{"city": "Beijing", "zip": "100000"},
+	}
+	merged := merge3(base, ours, theirs)
+	if !reflect.DeepEqual(merged, want) {
+		t.Errorf("merged %v want %v", merged, want)
+	}
+
+	_, err := ThreeWayMerge().Resolve("doc", []map[string]interface{}{ours, theirs}, nil)
+	if err != ErrNoCommonAncestor {
+		t.Errorf("resolve error %v want %v", err, ErrNoCommonAncestor)
+	}
+}
+
+func TestResolveConflicts(t *testing.T) {
+	docs := []map[string]interface{}{
+		{"_id": "conflicted", "_rev": "1-a", "name": "base", "count": 1},
+		{"_id": "conflicted", "_rev": "2-b", "_revisions": map[string]interface{}{"start": 2, "ids": []string{"b", "a"}}, "name": "base", "count": 2},
+		{"_id": "conflicted", "_rev": "2-c", "_revisions": map[string]interface{}{"start": 2, "ids": []string{"c", "a"}}, "name": "changed", "count": 1},
+	}
+	for _, doc := range docs {
+		_, err := testsDB.Update([]map[string]interface{}{doc}, map[string]interface{}{"new_edits": false})
+		if err != nil {
+			t.Fatal("db update error", err)
+		}
+	}
+
+	conflicted, err := testsDB.Conflicts()
+	if err != nil {
+		t.Fatal("db conflicts error", err)
+	}
+	found := false
+	for _, doc := range conflicted {
+		if doc.ID == "conflicted" {
+			found = len(doc.Conflicts) == 1
+		}
+	}
+	if !found {
+		t.Fatalf("conflicted doc not found in %v", conflicted)
+	}
+
+	leaves, err := testsDB.LeafRevisions("conflicted")
+	if err != nil {
+		t.Fatal("db leaf revisions error", err)
+	}
+	if len(leaves) != 2 {
+		t.Errorf("leaf revisions %d want 2", len(leaves))
+	}
+
+	results, err := testsDB.Resolve("conflicted", ThreeWayMerge())
+	if err != nil {
+		t.Fatal("db resolve error", err)
+	}
+	for _, result := range results {
+		if result.Err != nil {
+			t.Error("resolve update error", result.Err)
+		}
+	}
+
+	doc, err := testsDB.Get("conflicted", map[string][]string{"conflicts": {"true"}})
+	if err != nil {
+		t.Fatal("db get error", err)
+	}
+	if doc["name"] != "changed" || doc["count"].(float64) != 2 {
+		t.Errorf("resolved doc %v want name changed and count 2", doc)
+	}
+	if _, ok := doc["_conflicts"]; ok {
+		t.Errorf("resolved doc still has conflicts %v", doc["_conflicts"])
+	}
+}
@@ -9,10 +9,12 @@ import (
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"io/ioutil"
 	"math"
 	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"reflect"
 	"strconv"
 	"strings"
@@ -48,7 +50,9 @@ func getDefaultCouchDBURL() string {
 
 // Database represents a CouchDB database instance.
 type Database struct {
-	resource *Resource
+	resource        *Resource
+	uuids           UUIDGenerator
+	dropAttachments bool
 }
 
 // NewDatabase returns a CouchDB database instance.
@@ -87,6 +91,20 @@ func newDatabase(res *Resource) (*Database, error) {
 	}, nil
 }
 
+// SetUUIDGenerator sets the generator Save uses to assign IDs to documents with
+// no _id on the client side, passing nil to let the server generate them.
+func (d *Database) SetUUIDGenerator(gen UUIDGenerator) {
+	d.uuids = gen
+}
+
+// SetKeepAttachments sets whether Save and Set keep the existing attachments of
+// documents updated without _attachments, which they do by default at the cost of
+// fetching the stubs of the revision updated first. Passing false saves that
+// request, such updates then drop the attachments like CouchDB does.
+func (d *Database) SetKeepAttachments(keep bool) {
+	d.dropAttachments = !keep
+}
+
 // Available returns error if the database is not good to go.
 func (d *Database) Available() error {
 	_, _, err := d.resource.Head("", nil, nil)
@@ -99,12 +117,31 @@ func (d *Database) Available() error {
 // Trying to update an existing document with an incorrect _rev will cause failure.
 // *NOTE* It is recommended to avoid saving doc without _id and instead generate document ID on client side.
 // To avoid such problems you can generate a UUID on the client side.
-// GenerateUUID provides a simple, platform-independent implementation.
+// GenerateUUID provides a simple, platform-independent implementation, and
+// SetUUIDGenerator makes Save assign IDs with one of the UUIDGenerator implementations.
 // You can also use other third-party packages instead.
+// Attachments can be saved inline by putting an Attachment with Data, or a map with
+// "data" as []byte, into _attachments, the data is encoded as base64 and replaced
+// by a stub once saved. When updating a document without _attachments, or with new
+// inline ones, the existing attachments are kept, see SetKeepAttachments.
 // doc: the document to create or update.
 func (d *Database) Save(doc map[string]interface{}, options url.Values) (string, string, error) {
 	var id, rev string
 
+	if _, ok := doc["_id"]; !ok && d.uuids != nil {
+		uuid, err := d.uuids.NextUUID()
+		if err != nil {
+			return id, rev, err
+		}
+		doc["_id"] = uuid
+	}
+
+	docid, _ := doc["_id"].(string)
+	inline, err := d.prepareAttachments(docid, doc)
+	if err != nil {
+		return id, rev, err
+	}
+
 	var httpFunc func(string, http.Header, map[string]interface{}, url.Values) (http.Header, []byte, error)
 	if v, ok := doc["_id"]; ok {
 		httpFunc = docResource(d.resource, v.(string)).PutJSON
@@ -132,6 +169,7 @@ func (d *Database) Save(doc map[string]interface{}, options url.Values) (string,
 		rev = v.(string)
 		doc["_rev"] = rev
 	}
+	stubAttachments(doc, inline)
 
 	return id, rev, nil
 }
@@ -184,7 +222,13 @@ func deleteDoc(docRes *Resource, rev string) error {
 }
 
 // Set creates or updates a document with the specified ID.
+// Attachments are handled like in Save.
 func (d *Database) Set(docid string, doc map[string]interface{}) error {
+	inline, err := d.prepareAttachments(docid, doc)
+	if err != nil {
+		return err
+	}
+
 	docRes := docResource(d.resource, docid)
 	_, data, err := docRes.PutJSON("", nil, doc, nil)
 	if err != nil {
@@ -198,6 +242,7 @@ func (d *Database) Set(docid string, doc map[string]interface{}) error {
 
 	doc["_id"] = result["id"].(string)
 	doc["_rev"] = result["rev"].(string)
+	stubAttachments(doc, inline)
 	return nil
 }
 
@@ -208,16 +253,30 @@ func (d *Database) Contains(docid string) error {
 	return err
 }
 
-// UpdateResult represents result of an update.
+// UpdateResult represents result of an update. Error and Reason hold the error
+// id and message returned by CouchDB for the document, e.g. the message thrown by
+// a validate_doc_update function, Err the matching error.
 type UpdateResult struct {
 	ID, Rev string
 	Err     error
+	Error   string
+	Reason  string
+}
+
+// updateErrors maps the error ids of _bulk_docs to errors.
+var updateErrors = map[string]error{
+	"conflict":     ErrConflict,
+	"forbidden":    ErrForbidden,
+	"unauthorized": ErrUnauthorized,
+	"not_found":    ErrNotFound,
+	"bad_request":  ErrBadRequest,
 }
 
 // Update performs a bulk update or creation of the given documents in a single HTTP request.
 // It returns a 3-tuple (id, rev, error)
+// With the new_edits option set to false the documents are stored with the _rev
+// they hold, along with their _revisions history if any, like replication does.
 func (d *Database) Update(docs []map[string]interface{}, options map[string]interface{}) ([]UpdateResult, error) {
-	results := make([]UpdateResult, len(docs))
 	body := map[string]interface{}{}
 	if options != nil {
 		for k, v := range options {
@@ -230,42 +289,86 @@ func (d *Database) Update(docs []map[string]interface{}, options map[string]inte
 	if err != nil {
 		return nil, err
 	}
+	if newEdits, ok := body["new_edits"].(bool); ok && !newEdits {
+		return parseReplicatedResults(docs, data)
+	}
+	return parseUpdateResults(docs, data)
+}
+
+// updateResult returns the result of an entry of the response of _bulk_docs.
+func updateResult(v map[string]interface{}) UpdateResult {
+	result := UpdateResult{}
+	result.ID, _ = v["id"].(string)
+	result.Rev, _ = v["rev"].(string)
+	result.Error, _ = v["error"].(string)
+	result.Reason, _ = v["reason"].(string)
+	if _, ok := v["error"]; ok {
+		result.Rev = ""
+		result.Err = updateErrors[result.Error]
+		if result.Err == nil {
+			result.Err = ErrInternalServerError
+		}
+	}
+	return result
+}
+
+// parseUpdateResults parses the response of _bulk_docs into results, the
+// _id and _rev of the docs saved are updated.
+func parseUpdateResults(docs []map[string]interface{}, data []byte) ([]UpdateResult, error) {
+	results := make([]UpdateResult, len(docs))
 	var jsonArr []map[string]interface{}
-	err = json.Unmarshal(data, &jsonArr)
+	err := json.Unmarshal(data, &jsonArr)
 	if err != nil {
 		return nil, err
 	}
 
 	for i, v := range jsonArr {
-		var retErr error
-		var result UpdateResult
-		if val, ok := v["error"]; ok {
-			errMsg := val.(string)
-			switch errMsg {
-			case "conflict":
-				retErr = ErrConflict
-			case "forbidden":
-				retErr = ErrForbidden
-			default:
-				retErr = ErrInternalServerError
-			}
-			result = UpdateResult{
-				ID:  v["id"].(string),
-				Rev: "",
-				Err: retErr,
+		if i >= len(docs) {
+			break
+		}
+		results[i] = updateResult(v)
+		if results[i].Err == nil {
+			doc := docs[i]
+			doc["_id"] = results[i].ID
+			doc["_rev"] = results[i].Rev
+		}
+	}
+	return results, nil
+}
+
+// parseReplicatedResults parses the response of _bulk_docs with new_edits set
+// to false, which lists only the documents failed. A failure is matched to the
+// doc with the same _id and _rev, or to the first doc with the same _id not
+// matched yet if it holds no rev, as several revisions of a document may be
+// stored at once.
+func parseReplicatedResults(docs []map[string]interface{}, data []byte) ([]UpdateResult, error) {
+	var jsonArr []map[string]interface{}
+	err := json.Unmarshal(data, &jsonArr)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]UpdateResult, len(docs))
+	for i, doc := range docs {
+		id, _ := doc["_id"].(string)
+		rev, _ := doc["_rev"].(string)
+		results[i] = UpdateResult{ID: id, Rev: rev}
+	}
+
+	matched := make([]bool, len(docs))
+	for _, v := range jsonArr {
+		result := updateResult(v)
+		rev, _ := v["rev"].(string)
+		for i, doc := range docs {
+			if matched[i] || doc["_id"] != result.ID {
+				continue
 			}
-		} else {
-			id, rev := v["id"].(string), v["rev"].(string)
-			result = UpdateResult{
-				ID:  id,
-				Rev: rev,
-				Err: retErr,
+			if docRev, _ := doc["_rev"].(string); rev != "" && docRev != rev {
+				continue
 			}
-			doc := docs[i]
-			doc["_id"] = id
-			doc["_rev"] = rev
+			results[i], matched[i] = result, true
+			break
 		}
-		results[i] = result
 	}
 	return results, nil
 }
@@ -296,14 +399,16 @@ func (d *Database) DocIDs() ([]string, error) {
 	return ids, nil
 }
 
-// Name returns the name of database.
+// Name returns the name of database, taken from its URL if the server does not report it.
 func (d *Database) Name() (string, error) {
-	var name string
-	info, err := d.Info("")
+	info, err := d.DatabaseInfo()
 	if err != nil {
-		return name, err
+		return "", err
 	}
-	return info["db_name"].(string), nil
+	if info.DBName == "" {
+		return url.PathUnescape(path.Base(d.resource.base.EscapedPath()))
+	}
+	return info.DBName, nil
 }
 
 // Info returns the information about the database or design document
@@ -351,39 +456,14 @@ func (d *Database) Compact() error {
 }
 
 // Revisions returns all available revisions of the given document in reverse
-// order, e.g. latest first.
+// order, e.g. latest first. Revisions whose bodies have been compacted away are
+// skipped, use RevisionHistory to find out which ones.
 func (d *Database) Revisions(docid string, options url.Values) ([]map[string]interface{}, error) {
-	docRes := docResource(d.resource, docid)
-	_, data, err := docRes.GetJSON("", nil, url.Values{"revs": []string{"true"}})
+	history, err := d.RevisionHistory(docid, options)
 	if err != nil {
 		return nil, err
 	}
-	var jsonMap map[string]*json.RawMessage
-	err = json.Unmarshal(data, &jsonMap)
-	if err != nil {
-		return nil, err
-	}
-	var revsMap map[string]interface{}
-	err = json.Unmarshal(*jsonMap["_revisions"], &revsMap)
-	if err != nil {
-		return nil, err
-	}
-	startRev := int(revsMap["start"].(float64))
-	val := reflect.ValueOf(revsMap["ids"])
-	if options == nil {
-		options = url.Values{}
-	}
-	docs := make([]map[string]interface{}, val.Len())
-	for i := 0; i < val.Len(); i++ {
-		rev := fmt.Sprintf("%d-%s", startRev-i, val.Index(i).Interface().(string))
-		options.Set("rev", rev)
-		doc, err := d.Get(docid, options)
-		if err != nil {
-			return nil, err
-		}
-		docs[i] = doc
-	}
-	return docs, nil
+	return history.Docs, nil
 }
 
 // GetAttachment returns the file attachment associated with the document.
@@ -403,9 +483,12 @@ func (d *Database) GetAttachmentID(docid, name string) ([]byte, error) {
 }
 
 func (d *Database) getAttachment(docid, name string) ([]byte, error) {
-	docRes := docResource(docResource(d.resource, docid), name)
-	_, data, err := docRes.Get("", nil, nil)
-	return data, err
+	body, _, err := d.OpenAttachment(docid, name)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return ioutil.ReadAll(body)
 }
 
 // PutAttachment uploads the supplied []byte as an attachment to the specified document.
@@ -506,13 +589,18 @@ func (d *Database) Changes(options url.Values) (map[string]interface{}, error) {
 	return result, err
 }
 
-// Purge performs complete removing of the given documents.
+// Purge performs complete removing of the given documents, which must have _id
+// and _rev inside. Use PurgeRevs for typed results and large purges.
 func (d *Database) Purge(docs []map[string]interface{}) (map[string]interface{}, error) {
 	revs := map[string][]string{}
 	for _, doc := range docs {
-		id, rev := doc["_id"].(string), doc["_rev"].(string)
-		if _, ok := revs[id]; !ok {
-			revs[id] = []string{}
+		id, _ := doc["_id"].(string)
+		if id == "" {
+			return nil, errors.New("doc _id not existed")
+		}
+		rev, _ := doc["_rev"].(string)
+		if rev == "" {
+			return nil, errors.New("doc _rev not existed")
 		}
 		revs[id] = append(revs[id], rev)
 	}
@@ -585,11 +673,11 @@ func (d *Database) GetSecurity() (map[string]interface{}, error) {
 
 // Len returns the number of documents stored in it.
 func (d *Database) Len() (int, error) {
-	info, err := d.Info("")
+	info, err := d.DatabaseInfo()
 	if err != nil {
 		return 0, err
 	}
-	return int(info["doc_count"].(float64)), nil
+	return int(info.DocCount), nil
 }
 
 // GetRevsLimit gets the current revs_limit(revision limit) setting.
@@ -707,6 +795,15 @@ func (d *Database) Cleanup() error {
 // asc(field) sorts the field in ascending order, this is the default option while
 // desc(field) sorts the field in descending order.
 func (d *Database) Query(fields []string, selector string, sorts []string, limit, skip, index interface{}) ([]map[string]interface{}, error) {
+	find, err := findQuery(fields, selector, sorts, limit, skip, index)
+	if err != nil {
+		return nil, err
+	}
+	return d.queryJSON(find)
+}
+
+// findQuery returns the JSON query for _find built from the arguments of Query.
+func findQuery(fields []string, selector string, sorts []string, limit, skip, index interface{}) (map[string]interface{}, error) {
 	selectorJSON, err := parseSelectorSyntax(selector)
 	if err != nil {
 		return nil, err
@@ -739,7 +836,7 @@ func (d *Database) Query(fields []string, selector string, sorts []string, limit
 		find["use_index"] = index
 	}
 
-	return d.queryJSON(find)
+	return find, nil
 }
 
 // QueryJSON returns documents using a declarative JSON querying syntax.
@@ -32,6 +32,13 @@
 //
 // ViewField represents a view definition value bound to Document.
 //
+// Collection provides generic access to the documents of a database, decoding them
+// directly into user structs which embed Meta to round-trip _id, _rev and other
+// special fields. For example:
+//
+//  people := NewCollection[Person](db)
+//  person, err := people.Get("mike", nil)
+//
 // tools/replicate is a command-line tool for replicating databases from one CouchDB server to another.
 // This is mainly for backup purposes, but you can also use -continuous option to set up automatic replication.
 package couchdb
@@ -0,0 +1,294 @@
+package couchdb
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultDocCacheEntries = 1000
+	// changesTimeout is the timeout of the longpoll requests of DocCache.Listen in milliseconds
+	changesTimeout = 60000
+)
+
+// DocCacheOptions represents the options of a DocCache, zero values mean defaults.
+type DocCacheOptions struct {
+	// MaxEntries is the maximum number of documents cached, 1000 by default.
+	MaxEntries int
+	// TTL is how long a document is served from the cache, no limit by default.
+	TTL time.Duration
+}
+
+// DocCacheStats represents the statistics of a DocCache. Misses counts the
+// documents fetched from the database, Shared the misses served by a fetch
+// already in flight for the same document.
+type DocCacheStats struct {
+	Hits          int64
+	Misses        int64
+	Shared        int64
+	Evictions     int64
+	Invalidations int64
+}
+
+type docEntry struct {
+	id      string
+	data    []byte
+	expires time.Time
+}
+
+// docCall represents a fetch in flight shared by concurrent misses.
+type docCall struct {
+	wg   sync.WaitGroup
+	data []byte
+	err  error
+}
+
+// DocCache is an in-process LRU cache of the documents of a database, kept coherent
+// by Listen which evicts the documents changed according to the changes feed.
+// Concurrent misses of the same document share a single request. It is safe for
+// concurrent use.
+type DocCache struct {
+	db      *Database
+	options DocCacheOptions
+
+	mutex   sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+	calls   map[string]*docCall
+	version int64
+	stats   DocCacheStats
+
+	listening chan struct{}
+	started   sync.Once
+}
+
+// NewDocCache returns a newly-created *DocCache of the documents of db.
+func NewDocCache(db *Database, options *DocCacheOptions) *DocCache {
+	opts := DocCacheOptions{}
+	if options != nil {
+		opts = *options
+	}
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = defaultDocCacheEntries
+	}
+	return &DocCache{
+		db:        db,
+		options:   opts,
+		entries:   map[string]*list.Element{},
+		order:     list.New(),
+		calls:     map[string]*docCall{},
+		listening: make(chan struct{}),
+	}
+}
+
+// Get returns the document with the specified ID, from the cache if possible.
+// Each call returns a new map which may be modified freely.
+func (c *DocCache) Get(docid string) (map[string]interface{}, error) {
+	data, err := c.get(docid)
+	if err != nil {
+		return nil, err
+	}
+	return parseData(data)
+}
+
+// GetInto decodes the document with the specified ID into v, from the cache if possible.
+func (c *DocCache) GetInto(docid string, v interface{}) error {
+	data, err := c.get(docid)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (c *DocCache) get(docid string) ([]byte, error) {
+	c.mutex.Lock()
+	if elem, ok := c.entries[docid]; ok {
+		entry := elem.Value.(*docEntry)
+		if entry.expires.IsZero() || time.Now().Before(entry.expires) {
+			c.order.MoveToFront(elem)
+			c.stats.Hits++
+			c.mutex.Unlock()
+			return entry.data, nil
+		}
+		c.remove(elem)
+	}
+
+	if call, ok := c.calls[docid]; ok {
+		c.stats.Shared++
+		c.mutex.Unlock()
+		call.wg.Wait()
+		return call.data, call.err
+	}
+
+	call := &docCall{}
+	call.wg.Add(1)
+	c.calls[docid] = call
+	c.stats.Misses++
+	version := c.version
+	c.mutex.Unlock()
+
+	_, call.data, call.err = docResource(c.db.resource, docid).GetJSON("", nil, nil)
+	call.wg.Done()
+
+	c.mutex.Lock()
+	delete(c.calls, docid)
+	// documents changed while being fetched may be stale
+	if call.err == nil && version == c.version {
+		c.add(docid, call.data)
+	}
+	c.mutex.Unlock()
+	return call.data, call.err
+}
+
+// add caches data of the document, c.mutex must be held.
+func (c *DocCache) add(docid string, data []byte) {
+	entry := &docEntry{id: docid, data: data}
+	if c.options.TTL > 0 {
+		entry.expires = time.Now().Add(c.options.TTL)
+	}
+	if elem, ok := c.entries[docid]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[docid] = c.order.PushFront(entry)
+	for c.order.Len() > c.options.MaxEntries {
+		c.remove(c.order.Back())
+		c.stats.Evictions++
+	}
+}
+
+// remove removes the entry of elem, c.mutex must be held.
+func (c *DocCache) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*docEntry).id)
+}
+
+// Invalidate removes the documents with the specified IDs from the cache.
+func (c *DocCache) Invalidate(docids ...string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.version++
+	for _, id := range docids {
+		if elem, ok := c.entries[id]; ok {
+			c.remove(elem)
+			c.stats.Invalidations++
+		}
+	}
+}
+
+// Clear removes all the documents from the cache.
+func (c *DocCache) Clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.version++
+	c.entries = map[string]*list.Element{}
+	c.order.Init()
+}
+
+// Len returns the number of documents cached.
+func (c *DocCache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.order.Len()
+}
+
+// Stats returns the statistics of the cache.
+func (c *DocCache) Stats() DocCacheStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.stats
+}
+
+// Listening returns a channel closed once Listen has started following the
+// changes feed, the documents changed afterwards are evicted.
+func (c *DocCache) Listening() <-chan struct{} {
+	return c.listening
+}
+
+// Listen follows the changes feed of the database with longpoll requests and
+// evicts the documents changed, until ctx is done. It is meant to be run in its
+// own goroutine. The cache is cleared when Listen starts, and when a request
+// fails as changes may have been missed, in which case listening resumes after
+// a pause. Each time the current update sequence is read before the cache is
+// cleared, and the changes are followed from it. Returns ctx.Err().
+func (c *DocCache) Listen(ctx context.Context) error {
+	for {
+		since, err := c.currentSeq(ctx)
+		if err == nil {
+			c.Clear()
+			c.started.Do(func() { close(c.listening) })
+			err = c.follow(ctx, since)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		c.Clear()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(defaultPollInterval):
+		}
+	}
+}
+
+// currentSeq returns the sequence of the last change of the database.
+func (c *DocCache) currentSeq(ctx context.Context) (string, error) {
+	params := url.Values{}
+	params.Set("descending", "true")
+	params.Set("limit", "1")
+
+	_, data, err := c.db.resource.getContext(ctx, "_changes", nil, params)
+	if err != nil {
+		return "", err
+	}
+	var result struct {
+		LastSeq Sequence `json:"last_seq"`
+	}
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return "", err
+	}
+	return string(result.LastSeq), nil
+}
+
+// follow evicts the documents changed since the sequence until a request fails.
+func (c *DocCache) follow(ctx context.Context, since string) error {
+	for {
+		params := url.Values{}
+		params.Set("feed", "longpoll")
+		params.Set("since", since)
+		params.Set("timeout", strconv.Itoa(changesTimeout))
+
+		_, data, err := c.db.resource.getContext(ctx, "_changes", nil, params)
+		if err != nil {
+			return err
+		}
+
+		var result struct {
+			Results []Change[json.RawMessage] `json:"results"`
+			LastSeq Sequence                  `json:"last_seq"`
+		}
+		err = json.Unmarshal(data, &result)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]string, len(result.Results))
+		for i, change := range result.Results {
+			ids[i] = change.ID
+		}
+		if len(ids) > 0 {
+			c.Invalidate(ids...)
+		}
+		if result.LastSeq != "" {
+			since = string(result.LastSeq)
+		}
+	}
+}
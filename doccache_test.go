@@ -0,0 +1,139 @@
+package couchdb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDocCache(t *testing.T) {
+	doc := map[string]interface{}{"_id": "doccache", "value": 1}
+	_, _, err := testsDB.Save(doc, nil)
+	if err != nil {
+		t.Fatal("db save error", err)
+	}
+
+	cache := NewDocCache(testsDB, &DocCacheOptions{MaxEntries: 10})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go cache.Listen(ctx)
+	select {
+	case <-cache.Listening():
+	case <-time.After(5 * time.Second):
+		t.Fatal("doc cache not listening")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := cache.Get("doccache")
+			if err != nil {
+				t.Error("doc cache get error", err)
+				return
+			}
+			if got["value"].(float64) != 1 {
+				t.Errorf("doc cache value %v want 1", got["value"])
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats := cache.Stats()
+	if stats.Misses != 1 || stats.Hits+stats.Shared != 9 {
+		t.Errorf("doc cache stats %+v want 1 miss", stats)
+	}
+
+	doc["value"] = 2
+	_, _, err = testsDB.Save(doc, nil)
+	if err != nil {
+		t.Fatal("db save error", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got, err := cache.Get("doccache")
+		if err != nil {
+			t.Fatal("doc cache get error", err)
+		}
+		if got["value"].(float64) == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("doc cache not invalidated by the changes feed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDocCacheTTL(t *testing.T) {
+	cache := NewDocCache(testsDB, &DocCacheOptions{MaxEntries: 2, TTL: time.Millisecond})
+	cache.mutex.Lock()
+	cache.add("a", []byte(`{"_id":"a"}`))
+	cache.add("b", []byte(`{"_id":"b"}`))
+	cache.add("c", []byte(`{"_id":"c"}`))
+	cache.mutex.Unlock()
+
+	if cache.Len() != 2 || cache.Stats().Evictions != 1 {
+		t.Errorf("doc cache len %d stats %+v want 2 entries and 1 eviction", cache.Len(), cache.Stats())
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	cache.mutex.Lock()
+	entry := cache.entries["c"].Value.(*docEntry)
+	cache.mutex.Unlock()
+	if time.Now().Before(entry.expires) {
+		t.Error("doc cache entry not expired")
+	}
+}
+
+func TestDocCacheListenSince(t *testing.T) {
+	changes := make(chan string, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("descending") == "true" {
+			fmt.Fprint(w, `{"results":[],"last_seq":"5-abc"}`)
+			return
+		}
+		select {
+		case changes <- query.Get("since"):
+			fmt.Fprint(w, `{"results":[{"id":"a","seq":"6-abc","changes":[]}],"last_seq":"6-abc"}`)
+		case <-r.Context().Done():
+		}
+	}))
+	defer ts.Close()
+
+	db, err := NewDatabase(ts.URL + "/doccache")
+	if err != nil {
+		t.Fatal("new database error", err)
+	}
+	cache := NewDocCache(db, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- cache.Listen(ctx) }()
+
+	<-cache.Listening()
+	cache.mutex.Lock()
+	cache.add("a", []byte(`{"_id":"a"}`))
+	cache.mutex.Unlock()
+
+	if since := <-changes; since != "5-abc" {
+		t.Errorf("changes since %s want 5-abc", since)
+	}
+	if since := <-changes; since != "6-abc" {
+		t.Errorf("changes since %s want 6-abc", since)
+	}
+	if cache.Len() != 0 || cache.Stats().Invalidations != 1 {
+		t.Errorf("doc cache len %d stats %+v want a invalidated", cache.Len(), cache.Stats())
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("doc cache listen error %v want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,156 @@
+package couchdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const defaultPollInterval = time.Second
+
+// Welcome represents the welcome message returned by the root of CouchDB server.
+type Welcome struct {
+	CouchDB  string   `json:"couchdb"`
+	Version  string   `json:"version"`
+	GitSHA   string   `json:"git_sha"`
+	UUID     string   `json:"uuid"`
+	Features []string `json:"features"`
+	Vendor   struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	} `json:"vendor"`
+}
+
+// Welcome returns the welcome message of CouchDB server.
+func (s *Server) Welcome(ctx context.Context) (*Welcome, error) {
+	_, data, err := s.resource.getContext(ctx, "", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	welcome := &Welcome{}
+	err = json.Unmarshal(data, welcome)
+	if err != nil {
+		return nil, err
+	}
+	return welcome, nil
+}
+
+// Up returns nil if the server is up and ready to serve requests. It returns
+// ErrNotFound if the node is in maintenance mode.
+func (s *Server) Up(ctx context.Context) error {
+	_, data, err := s.resource.getContext(ctx, "_up", nil, nil)
+	if err != nil {
+		return err
+	}
+	result, err := parseData(data)
+	if err != nil {
+		return err
+	}
+	if status, _ := result["status"].(string); status != "ok" {
+		return fmt.Errorf("server status %s", status)
+	}
+	return nil
+}
+
+// WaitReady probes the server every interval until it is up or ctx is done.
+// When ctx is done the last probe error is returned along with ctx.Err().
+func (s *Server) WaitReady(ctx context.Context, interval time.Duration) error {
+	return poll(ctx, interval, func() error {
+		return s.Up(ctx)
+	})
+}
+
+// WaitDatabase probes the server every interval until it is up and the database
+// with the given name is available or ctx is done.
+func (s *Server) WaitDatabase(ctx context.Context, name string, interval time.Duration) error {
+	err := s.WaitReady(ctx, interval)
+	if err != nil {
+		return err
+	}
+	return poll(ctx, interval, func() error {
+		_, _, err := s.resource.headContext(ctx, name, nil, nil)
+		return err
+	})
+}
+
+// poll calls probe every interval until it succeeds or ctx is done.
+func poll(ctx context.Context, interval time.Duration, probe func() error) error {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		err := probe()
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %v", ctx.Err(), err)
+		case <-ticker.C:
+		}
+	}
+}
+
+// Health represents the health report served by the handler of HealthHandler.
+type Health struct {
+	Status    string  `json:"status"`
+	Version   string  `json:"version,omitempty"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// HealthHandler returns an http.Handler suitable for readiness and liveness probes.
+// It responds with 200 and a Health report if the server is up and all the
+// databases given are available within timeout, otherwise it responds with 503.
+func (s *Server) HealthHandler(timeout time.Duration, dbs ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		health := Health{Status: "ok"}
+		start := time.Now()
+		err := s.checkHealth(ctx, &health, dbs)
+		health.LatencyMS = float64(time.Since(start)) / float64(time.Millisecond)
+
+		status := http.StatusOK
+		if err != nil {
+			status = http.StatusServiceUnavailable
+			health.Status = "unavailable"
+			health.Error = err.Error()
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(health)
+	})
+}
+
+func (s *Server) checkHealth(ctx context.Context, health *Health, dbs []string) error {
+	welcome, err := s.Welcome(ctx)
+	if err != nil {
+		return err
+	}
+	health.Version = welcome.Version
+
+	err = s.Up(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, db := range dbs {
+		_, _, err = s.resource.headContext(ctx, db, nil, nil)
+		if err != nil {
+			return fmt.Errorf("database %s: %v", db, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package couchdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWelcomeAndUp(t *testing.T) {
+	welcome, err := server.Welcome(context.Background())
+	if err != nil {
+		t.Fatal("server welcome error", err)
+	}
+	if welcome.CouchDB != "Welcome" {
+		t.Errorf("welcome %s want Welcome", welcome.CouchDB)
+	}
+
+	version, err := server.Version()
+	if err != nil {
+		t.Fatal("server version error", err)
+	}
+	if welcome.Version != version {
+		t.Errorf("welcome version %s want %s", welcome.Version, version)
+	}
+
+	err = server.Up(context.Background())
+	if err != nil {
+		t.Error("server up error", err)
+	}
+}
+
+func TestWaitDatabase(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := server.WaitDatabase(ctx, "golang-tests", 100*time.Millisecond)
+	if err != nil {
+		t.Error("server wait database error", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	err = server.WaitDatabase(ctx, "golang-missing", 100*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("server wait missing database error %v want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestPollContextError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := poll(ctx, time.Millisecond, func() error { return ErrNotFound })
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("poll error %v want %v", err, context.Canceled)
+	}
+}
+
+func TestHealthHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	server.HealthHandler(time.Second, "golang-tests").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("health status %d want %d", rec.Code, http.StatusOK)
+	}
+
+	health := Health{}
+	err := json.Unmarshal(rec.Body.Bytes(), &health)
+	if err != nil {
+		t.Fatal("health unmarshal error", err)
+	}
+	if health.Status != "ok" || health.Version == "" {
+		t.Errorf("health %+v want status ok with version", health)
+	}
+
+	rec = httptest.NewRecorder()
+	server.HealthHandler(time.Second, "golang-missing").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("health status %d want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
@@ -0,0 +1,134 @@
+package couchdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Sizes represents the sizes of a database or view index in bytes. File is
+// the size of the file on disk, Active the size of the live data in it and
+// External the uncompressed size of the data.
+type Sizes struct {
+	Active   int64 `json:"active"`
+	External int64 `json:"external"`
+	File     int64 `json:"file"`
+}
+
+// Fragmentation returns the ratio of the file taken by data no longer live,
+// from 0 to 1, which compaction would reclaim.
+func (s Sizes) Fragmentation() float64 {
+	if s.File <= 0 || s.Active > s.File {
+		return 0
+	}
+	return float64(s.File-s.Active) / float64(s.File)
+}
+
+// DatabaseInfo represents the information about a database. UpdateSeq and
+// PurgeSeq are opaque on CouchDB 2.0 and later.
+type DatabaseInfo struct {
+	DBName            string   `json:"db_name"`
+	DocCount          int64    `json:"doc_count"`
+	DocDelCount       int64    `json:"doc_del_count"`
+	UpdateSeq         Sequence `json:"update_seq"`
+	PurgeSeq          Sequence `json:"purge_seq"`
+	CompactRunning    bool     `json:"compact_running"`
+	Sizes             Sizes    `json:"sizes"`
+	InstanceStartTime string   `json:"instance_start_time"`
+	DiskFormatVersion int      `json:"disk_format_version"`
+	Cluster           struct {
+		Q int `json:"q"`
+		N int `json:"n"`
+		W int `json:"w"`
+		R int `json:"r"`
+	} `json:"cluster"`
+	Props struct {
+		Partitioned bool `json:"partitioned"`
+	} `json:"props"`
+}
+
+// Fragmentation returns the ratio of the database file which compaction would reclaim.
+func (i *DatabaseInfo) Fragmentation() float64 {
+	return i.Sizes.Fragmentation()
+}
+
+// ViewIndexInfo represents the information about the view index of a design document.
+type ViewIndexInfo struct {
+	Signature      string   `json:"signature"`
+	Language       string   `json:"language"`
+	Sizes          Sizes    `json:"sizes"`
+	UpdateSeq      Sequence `json:"update_seq"`
+	PurgeSeq       Sequence `json:"purge_seq"`
+	UpdaterRunning bool     `json:"updater_running"`
+	CompactRunning bool     `json:"compact_running"`
+	WaitingClients int      `json:"waiting_clients"`
+	WaitingCommit  bool     `json:"waiting_commit"`
+}
+
+// Fragmentation returns the ratio of the index file which compaction would reclaim.
+func (i *ViewIndexInfo) Fragmentation() float64 {
+	return i.Sizes.Fragmentation()
+}
+
+// DesignDocInfo represents the information about a design document and its view index.
+type DesignDocInfo struct {
+	Name      string        `json:"name"`
+	ViewIndex ViewIndexInfo `json:"view_index"`
+}
+
+// legacySizes holds the sizes reported by CouchDB 1.x
+type legacySizes struct {
+	DiskSize int64 `json:"disk_size"`
+	DataSize int64 `json:"data_size"`
+}
+
+func (l legacySizes) fill(sizes *Sizes) {
+	if sizes.File == 0 && sizes.Active == 0 {
+		sizes.File, sizes.Active = l.DiskSize, l.DataSize
+	}
+}
+
+// DatabaseInfo returns the typed information about the database.
+func (d *Database) DatabaseInfo() (*DatabaseInfo, error) {
+	_, data, err := d.resource.GetJSON("", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &DatabaseInfo{}
+	err = json.Unmarshal(data, info)
+	if err != nil {
+		return nil, err
+	}
+	var legacy legacySizes
+	if json.Unmarshal(data, &legacy) == nil {
+		legacy.fill(&info.Sizes)
+	}
+	return info, nil
+}
+
+// DesignDocInfo returns the typed information about the design document, which
+// may be given with or without the _design/ prefix.
+func (d *Database) DesignDocInfo(ddoc string) (*DesignDocInfo, error) {
+	ddoc = strings.TrimPrefix(ddoc, "_design/")
+	_, data, err := d.resource.GetJSON(fmt.Sprintf("_design/%s/_info", ddoc), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		DesignDocInfo
+		ViewIndex struct {
+			ViewIndexInfo
+			legacySizes
+		} `json:"view_index"`
+	}
+	err = json.Unmarshal(data, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &DesignDocInfo{Name: response.Name, ViewIndex: response.ViewIndex.ViewIndexInfo}
+	response.ViewIndex.legacySizes.fill(&info.ViewIndex.Sizes)
+	return info, nil
+}
@@ -0,0 +1,53 @@
+package couchdb
+
+import (
+	"testing"
+)
+
+func TestDatabaseInfo(t *testing.T) {
+	info, err := testsDB.DatabaseInfo()
+	if err != nil {
+		t.Fatal("db database info error", err)
+	}
+	if info.DBName != "golang-tests" {
+		t.Errorf("db name %s want golang-tests", info.DBName)
+	}
+	if info.Sizes.File <= 0 || info.UpdateSeq == "" {
+		t.Errorf("db info %+v want file size and update seq", info)
+	}
+	if f := info.Fragmentation(); f < 0 || f > 1 {
+		t.Errorf("db fragmentation %f want between 0 and 1", f)
+	}
+}
+
+func TestTypedDesignDocInfo(t *testing.T) {
+	designDB.Set("_design/typed", map[string]interface{}{
+		"language": "javascript",
+		"views": map[string]interface{}{
+			"test": map[string]string{"map": "function(doc) { emit(doc.type, null); }"},
+		},
+	})
+	info, err := designDB.DesignDocInfo("_design/typed")
+	if err != nil {
+		t.Fatal("db design doc info error", err)
+	}
+	if info.Name != "typed" || info.ViewIndex.Signature == "" || info.ViewIndex.Language != "javascript" {
+		t.Errorf("design doc info %+v", info)
+	}
+}
+
+func TestFragmentation(t *testing.T) {
+	tests := []struct {
+		sizes Sizes
+		want  float64
+	}{
+		{Sizes{Active: 25, File: 100}, 0.75},
+		{Sizes{Active: 100, File: 100}, 0},
+		{Sizes{}, 0},
+	}
+	for _, test := range tests {
+		if got := test.sizes.Fragmentation(); got != test.want {
+			t.Errorf("fragmentation of %+v = %f want %f", test.sizes, got, test.want)
+		}
+	}
+}
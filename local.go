@@ -0,0 +1,101 @@
+package couchdb
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+const localPrefix = "_local/"
+
+// localResource returns a Resource instance for the local document with id,
+// which may be given with or without the _local/ prefix.
+func localResource(res *Resource, id string) *Resource {
+	return docResource(docResource(res, "_local"), strings.TrimPrefix(id, localPrefix))
+}
+
+// GetLocal decodes the local document with the specified ID into v, which is
+// usually a pointer to a struct embedding Meta or a map.
+//
+// Local documents live under the _local/ prefix, which may be omitted from id.
+// They are never replicated, not listed in _all_docs or the changes feed, and keep
+// no revision history, which makes them suited to replication checkpoints and
+// per-node state.
+func (d *Database) GetLocal(id string, v interface{}) error {
+	_, data, err := localResource(d.resource, id).GetJSON("", nil, nil)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// PutLocal creates or updates the local document with the specified ID with the
+// JSON encoding of v, and returns the new revision. To update an existing document
+// v must contain its current _rev, otherwise it fails with ErrConflict. If v has a
+// _rev field it is set to the new revision. Local documents are never replicated.
+func (d *Database) PutLocal(id string, v interface{}) (string, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	_, data, err := localResource(d.resource, id).Put("", nil, body, nil)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := parseData(data)
+	if err != nil {
+		return "", err
+	}
+	rev, _ := result["rev"].(string)
+
+	switch doc := v.(type) {
+	case map[string]interface{}:
+		doc["_rev"] = rev
+	default:
+		if reflect.ValueOf(v).Kind() == reflect.Ptr {
+			data, _ = json.Marshal(map[string]string{"_rev": rev})
+			err = json.Unmarshal(data, v)
+		}
+	}
+	return rev, err
+}
+
+// UpdateLocal applies mutate to the local document with the specified ID and saves it,
+// retrying on conflict like UpdateFunc. Returns the new revision.
+func (d *Database) UpdateLocal(id string, policy *RetryPolicy, mutate func(doc map[string]interface{}) error) (string, error) {
+	var rev string
+	err := policy.retry(func() error {
+		doc := map[string]interface{}{}
+		err := d.GetLocal(id, &doc)
+		if err == ErrNotFound {
+			doc = map[string]interface{}{"_id": localPrefix + strings.TrimPrefix(id, localPrefix)}
+		} else if err != nil {
+			return err
+		}
+
+		err = mutate(doc)
+		if err != nil {
+			return err
+		}
+
+		rev, err = d.PutLocal(id, doc)
+		return err
+	})
+	return rev, err
+}
+
+// DeleteLocal deletes the local document with the specified ID and revision.
+func (d *Database) DeleteLocal(id, rev string) error {
+	return deleteDoc(localResource(d.resource, id), rev)
+}
+
+// ListLocal returns the rows of the local documents in the database, available since CouchDB 2.2.
+func (d *Database) ListLocal(options *AllDocsOptions) ([]AllDocsRow, error) {
+	result, err := d.LocalDocs(options)
+	if err != nil {
+		return nil, err
+	}
+	return result.Rows, nil
+}
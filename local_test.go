@@ -0,0 +1,101 @@
+package couchdb
+
+import (
+	"testing"
+)
+
+type checkpoint struct {
+	LastSeq string `json:"last_seq"`
+	Meta
+}
+
+func TestLocalDocs(t *testing.T) {
+	cp := &checkpoint{LastSeq: "1-abc"}
+	rev, err := testsDB.PutLocal("checkpoint", cp)
+	if err != nil {
+		t.Fatal("db put local error", err)
+	}
+	if cp.Rev != rev {
+		t.Errorf("checkpoint rev %s want %s", cp.Rev, rev)
+	}
+
+	cp.LastSeq = "2-def"
+	_, err = testsDB.PutLocal("_local/checkpoint", cp)
+	if err != nil {
+		t.Fatal("db put local error", err)
+	}
+
+	_, err = testsDB.PutLocal("checkpoint", &checkpoint{LastSeq: "stale", Meta: Meta{Rev: rev}})
+	if err != ErrConflict {
+		t.Errorf("db put local stale error %v want %v", err, ErrConflict)
+	}
+
+	got := &checkpoint{}
+	err = testsDB.GetLocal("checkpoint", got)
+	if err != nil {
+		t.Fatal("db get local error", err)
+	}
+	if got.LastSeq != "2-def" || got.ID != "_local/checkpoint" {
+		t.Errorf("checkpoint %+v want last seq 2-def", got)
+	}
+
+	_, err = testsDB.UpdateLocal("checkpoint", nil, func(doc map[string]interface{}) error {
+		doc["last_seq"] = "3-ghi"
+		return nil
+	})
+	if err != nil {
+		t.Fatal("db update local error", err)
+	}
+
+	rows, err := testsDB.ListLocal(nil)
+	if err != nil {
+		t.Fatal("db list local error", err)
+	}
+	found := ""
+	for _, row := range rows {
+		if row.ID == "_local/checkpoint" {
+			found = row.Value.Rev
+		}
+	}
+	if found == "" {
+		t.Fatalf("local doc not listed in %v", rows)
+	}
+
+	err = testsDB.DeleteLocal("checkpoint", found)
+	if err != nil {
+		t.Fatal("db delete local error", err)
+	}
+
+	err = testsDB.GetLocal("checkpoint", got)
+	if err != ErrNotFound {
+		t.Errorf("db get deleted local error %v want %v", err, ErrNotFound)
+	}
+}
+
+func TestUpdateLocalSlash(t *testing.T) {
+	id := "checkpoint/node1"
+	for i := 0; i < 2; i++ {
+		_, err := testsDB.UpdateLocal(id, nil, func(doc map[string]interface{}) error {
+			count, _ := doc["count"].(float64)
+			doc["count"] = count + 1
+			return nil
+		})
+		if err != nil {
+			t.Fatal("db update local error", err)
+		}
+	}
+
+	got := map[string]interface{}{}
+	err := testsDB.GetLocal(id, &got)
+	if err != nil {
+		t.Fatal("db get local error", err)
+	}
+	if got["count"] != float64(2) || got["_id"] != "_local/"+id {
+		t.Errorf("local doc %v want count 2", got)
+	}
+
+	err = testsDB.DeleteLocal(id, got["_rev"].(string))
+	if err != nil {
+		t.Fatal("db delete local error", err)
+	}
+}
@@ -140,6 +140,41 @@ func Load(db *Database, docID string, obj interface{}) error {
 	return nil
 }
 
+// StoreFunc loads the document with docID into obj, applies mutate and stores it.
+// When storing fails with ErrConflict the document is loaded again and mutate
+// reapplied, as many times as policy allows, DefaultRetryPolicy is used if policy is nil.
+// If the document does not exist, obj is reset to its zero value with docID set.
+// obj: a Document-embedded struct value, must be a pointer value.
+func StoreFunc(db *Database, docID string, obj interface{}, policy *RetryPolicy, mutate func() error) error {
+	ptrValue := reflect.ValueOf(obj)
+	if ptrValue.Kind() != reflect.Ptr || ptrValue.Elem().Kind() != reflect.Struct {
+		return ErrNotStruct
+	}
+
+	if ptrValue.Elem().FieldByName("Document") == zero {
+		return ErrNotDocumentEmbedded
+	}
+
+	return policy.retry(func() error {
+		// reset obj so that nothing of the previous attempt is left
+		ptrValue.Elem().Set(reflect.Zero(ptrValue.Elem().Type()))
+		err := Load(db, docID, obj)
+		if err == ErrNotFound {
+			setIDMethod := ptrValue.MethodByName("SetID")
+			setIDMethod.Call([]reflect.Value{reflect.ValueOf(docID)})
+		} else if err != nil {
+			return err
+		}
+
+		err = mutate()
+		if err != nil {
+			return err
+		}
+
+		return Store(db, obj)
+	})
+}
+
 // FromJSONCompatibleMap constructs a Document-embedded struct from a JSON-compatible map.
 func FromJSONCompatibleMap(obj interface{}, docMap map[string]interface{}) error {
 	ptrValue := reflect.ValueOf(obj)
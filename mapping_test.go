@@ -385,3 +385,49 @@ func TestView(t *testing.T) {
 		}
 	}
 }
+
+func TestStoreFunc(t *testing.T) {
+	post := Post{}
+	err := StoreFunc(mappingDB, "store_func", &post, nil, func() error {
+		post.Title = post.Title + "a"
+		return nil
+	})
+	if err != nil {
+		t.Fatal("store func error", err)
+	}
+
+	// store a concurrent change on the first attempt so that it conflicts
+	attempts := 0
+	stale := Post{}
+	err = StoreFunc(mappingDB, "store_func", &post, &RetryPolicy{MaxAttempts: 3}, func() error {
+		attempts++
+		if attempts == 1 {
+			stale = Post{}
+			if err := Load(mappingDB, "store_func", &stale); err != nil {
+				return err
+			}
+			concurrent := stale
+			concurrent.Title = concurrent.Title + "c"
+			if err := Store(mappingDB, &concurrent); err != nil {
+				return err
+			}
+		}
+		post.Title = post.Title + "b"
+		return nil
+	})
+	if err != nil {
+		t.Fatal("store func error", err)
+	}
+
+	if attempts != 2 {
+		t.Errorf("store func attempts %d want 2", attempts)
+	}
+	if post.Title != "acb" || post.GetID() != "store_func" {
+		t.Errorf("post title %s id %s want acb store_func", post.Title, post.GetID())
+	}
+
+	err = Store(mappingDB, &stale)
+	if err != ErrConflict {
+		t.Errorf("store stale error %v want %v", err, ErrConflict)
+	}
+}
@@ -0,0 +1,296 @@
+package couchdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+// MultipartAttachment represents an attachment uploaded along with its document
+// by Database.SaveMultipart. Length is the number of bytes Body provides, if it is
+// negative Body is read into memory to find it out.
+type MultipartAttachment struct {
+	Name        string
+	ContentType string
+	Length      int64
+	Body        io.Reader
+}
+
+// SaveMultipart creates or updates the document together with the attachments
+// in a single multipart/related request, creating only one revision and sending
+// the attachments as raw bytes instead of base64. Existing attachments not among
+// atts are kept as long as doc holds their stubs, as returned by Get.
+// If doc has no _id one is generated, by the UUID generator of the database if set.
+// The _id, _rev and _attachments of doc are updated after saved.
+func (d *Database) SaveMultipart(doc map[string]interface{}, atts []MultipartAttachment) (string, string, error) {
+	id, _ := doc["_id"].(string)
+	if id == "" {
+		if d.uuids != nil {
+			uuid, err := d.uuids.NextUUID()
+			if err != nil {
+				return "", "", err
+			}
+			id = uuid
+		} else {
+			id = GenerateUUID()
+		}
+	}
+
+	// the attachment parts must follow in the same order as _attachments
+	// is encoded, which is sorted by name
+	atts = append([]MultipartAttachment{}, atts...)
+	sort.Slice(atts, func(i, j int) bool { return atts[i].Name < atts[j].Name })
+
+	stubs := map[string]interface{}{}
+	if existing, ok := doc["_attachments"].(map[string]interface{}); ok {
+		for name, stub := range existing {
+			stubs[name] = stub
+		}
+	}
+
+	for i, att := range atts {
+		if att.Length < 0 {
+			data, err := ioutil.ReadAll(att.Body)
+			if err != nil {
+				return "", "", err
+			}
+			atts[i].Body, atts[i].Length = bytes.NewReader(data), int64(len(data))
+		}
+		stubs[att.Name] = map[string]interface{}{
+			"follows":      true,
+			"content_type": att.ContentType,
+			"length":       atts[i].Length,
+		}
+	}
+
+	body := map[string]interface{}{}
+	for k, v := range doc {
+		body[k] = v
+	}
+	body["_id"] = id
+	body["_attachments"] = stubs
+	docJSON, err := json.Marshal(body)
+	if err != nil {
+		return "", "", err
+	}
+
+	content, length, boundary, err := multipartBody(docJSON, atts)
+	if err != nil {
+		return "", "", err
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", mime.FormatMediaType("multipart/related", map[string]string{"boundary": boundary}))
+	rsp, err := docResource(d.resource, id).stream(context.Background(), http.MethodPut, "", header, content, length, nil)
+	if err != nil {
+		return "", "", err
+	}
+	defer rsp.Body.Close()
+	data, err := ioutil.ReadAll(rsp.Body)
+	if err != nil {
+		return "", "", err
+	}
+
+	result, err := parseData(data)
+	if err != nil {
+		return "", "", err
+	}
+	id, _ = result["id"].(string)
+	rev, _ := result["rev"].(string)
+
+	for _, att := range atts {
+		stubs[att.Name] = map[string]interface{}{
+			"stub":         true,
+			"content_type": att.ContentType,
+			"length":       att.Length,
+		}
+	}
+	doc["_id"] = id
+	doc["_rev"] = rev
+	doc["_attachments"] = stubs
+	return id, rev, nil
+}
+
+// multipartBody returns a multipart/related body made of the JSON document
+// followed by the attachments, along with its length and boundary.
+func multipartBody(docJSON []byte, atts []MultipartAttachment) (io.Reader, int64, string, error) {
+	var readers []io.Reader
+	var length int64
+	buf := &bytes.Buffer{}
+	writer := multipart.NewWriter(buf)
+
+	// flush moves what the writer has written so far into readers
+	flush := func() {
+		data := make([]byte, buf.Len())
+		copy(data, buf.Bytes())
+		readers = append(readers, bytes.NewReader(data))
+		length += int64(len(data))
+		buf.Reset()
+	}
+
+	docHeader := textproto.MIMEHeader{}
+	docHeader.Set("Content-Type", "application/json")
+	part, err := writer.CreatePart(docHeader)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	_, err = part.Write(docJSON)
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	for _, att := range atts {
+		attHeader := textproto.MIMEHeader{}
+		attHeader.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": att.Name}))
+		if att.ContentType != "" {
+			attHeader.Set("Content-Type", att.ContentType)
+		}
+		attHeader.Set("Content-Length", strconv.FormatInt(att.Length, 10))
+		_, err = writer.CreatePart(attHeader)
+		if err != nil {
+			return nil, 0, "", err
+		}
+		flush()
+		readers = append(readers, io.LimitReader(att.Body, att.Length))
+		length += att.Length
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return nil, 0, "", err
+	}
+	flush()
+
+	return io.MultiReader(readers...), length, writer.Boundary(), nil
+}
+
+// MultipartOptions represents the options of Database.GetMultipart.
+type MultipartOptions struct {
+	// Rev is the revision to fetch, the winning revision by default.
+	Rev string
+	// AttsSince includes only the attachments changed since the given revisions,
+	// the others are left as stubs.
+	AttsSince []string
+}
+
+// AttachmentPart represents an attachment streamed by MultipartDocument.
+type AttachmentPart struct {
+	Name        string
+	ContentType string
+	Encoding    string
+	Body        io.Reader
+}
+
+// MultipartDocument represents a document fetched along with its attachments
+// in a multipart/related response, the attachments are streamed one at a time.
+type MultipartDocument struct {
+	Doc map[string]interface{}
+
+	body   io.ReadCloser
+	reader *multipart.Reader
+}
+
+// NextAttachment returns the next attachment, io.EOF if there are no more.
+// The body of the previous attachment must not be read afterwards.
+func (md *MultipartDocument) NextAttachment() (*AttachmentPart, error) {
+	if md.reader == nil {
+		return nil, io.EOF
+	}
+
+	part, err := md.reader.NextPart()
+	if err != nil {
+		return nil, err
+	}
+
+	name := ""
+	if _, params, err := mime.ParseMediaType(part.Header.Get("Content-Disposition")); err == nil {
+		name = params["filename"]
+	}
+
+	return &AttachmentPart{
+		Name:        name,
+		ContentType: part.Header.Get("Content-Type"),
+		Encoding:    part.Header.Get("Content-Encoding"),
+		Body:        part,
+	}, nil
+}
+
+// Close closes the response, it must be called once done with the document.
+func (md *MultipartDocument) Close() error {
+	return md.body.Close()
+}
+
+// GetMultipart fetches the document with the specified ID along with its
+// attachments in one multipart/related response. The document is decoded at
+// once while the attachments are streamed part by part with NextAttachment,
+// avoiding base64 inflation and holding them all in memory.
+func (d *Database) GetMultipart(docid string, options *MultipartOptions) (*MultipartDocument, error) {
+	params := url.Values{}
+	params.Set("attachments", "true")
+	if options != nil {
+		if options.Rev != "" {
+			params.Set("rev", options.Rev)
+		}
+		if len(options.AttsSince) > 0 {
+			since, err := json.Marshal(options.AttsSince)
+			if err != nil {
+				return nil, err
+			}
+			params.Set("atts_since", string(since))
+		}
+	}
+
+	header := http.Header{}
+	header.Set("Accept", "multipart/related, application/json")
+	rsp, err := docResource(d.resource, docid).stream(context.Background(), http.MethodGet, "", header, nil, -1, params)
+	if err != nil {
+		return nil, err
+	}
+
+	md := &MultipartDocument{body: rsp.Body}
+	mediaType, mediaParams, err := mime.ParseMediaType(rsp.Header.Get("Content-Type"))
+	if err != nil {
+		rsp.Body.Close()
+		return nil, err
+	}
+
+	// documents without attachments come back as plain JSON
+	if mediaType != "multipart/related" {
+		md.Doc, err = parseJSONBody(rsp.Body)
+		if err != nil {
+			rsp.Body.Close()
+			return nil, err
+		}
+		return md, nil
+	}
+
+	md.reader = multipart.NewReader(rsp.Body, mediaParams["boundary"])
+	part, err := md.reader.NextPart()
+	if err != nil {
+		rsp.Body.Close()
+		return nil, err
+	}
+	md.Doc, err = parseJSONBody(part)
+	if err != nil {
+		rsp.Body.Close()
+		return nil, err
+	}
+	return md, nil
+}
+
+func parseJSONBody(r io.Reader) (map[string]interface{}, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return parseData(data)
+}
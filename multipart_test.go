@@ -0,0 +1,85 @@
+package couchdb
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestMultipartSaveAndGet(t *testing.T) {
+	doc := map[string]interface{}{"_id": "multipart", "type": "report"}
+	files := map[string]string{
+		"a.txt": "first attachment",
+		"b.txt": "second attachment",
+		"c.txt": "third attachment",
+	}
+	atts := []MultipartAttachment{}
+	for name, content := range files {
+		atts = append(atts, MultipartAttachment{
+			Name:        name,
+			ContentType: "text/plain",
+			Length:      int64(len(content)),
+			Body:        strings.NewReader(content),
+		})
+	}
+
+	_, rev, err := testsDB.SaveMultipart(doc, atts)
+	if err != nil {
+		t.Fatal("db save multipart error", err)
+	}
+	if !strings.HasPrefix(rev, "1-") {
+		t.Errorf("rev %s want 1-", rev)
+	}
+
+	// add one more attachment and keep the existing ones
+	_, rev, err = testsDB.SaveMultipart(doc, []MultipartAttachment{
+		{Name: "d.bin", ContentType: "application/octet-stream", Length: -1, Body: bytes.NewReader([]byte{0, 1, 2, 3})},
+	})
+	if err != nil {
+		t.Fatal("db save multipart error", err)
+	}
+	files["d.bin"] = string([]byte{0, 1, 2, 3})
+
+	md, err := testsDB.GetMultipart("multipart", nil)
+	if err != nil {
+		t.Fatal("db get multipart error", err)
+	}
+	defer md.Close()
+
+	if md.Doc["_rev"] != rev || md.Doc["type"] != "report" {
+		t.Errorf("multipart doc %v want rev %s", md.Doc, rev)
+	}
+
+	count := 0
+	for {
+		att, err := md.NextAttachment()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal("next attachment error", err)
+		}
+		data, err := ioutil.ReadAll(att.Body)
+		if err != nil {
+			t.Fatal("read attachment error", err)
+		}
+		if string(data) != files[att.Name] {
+			t.Errorf("attachment %s content %q want %q", att.Name, data, files[att.Name])
+		}
+		count++
+	}
+	if count != len(files) {
+		t.Errorf("attachments %d want %d", count, len(files))
+	}
+
+	md, err = testsDB.GetMultipart("multipart", &MultipartOptions{AttsSince: []string{rev}})
+	if err != nil {
+		t.Fatal("db get multipart error", err)
+	}
+	defer md.Close()
+	if _, err = md.NextAttachment(); err != io.EOF {
+		t.Errorf("next attachment error %v want %v", err, io.EOF)
+	}
+}
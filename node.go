@@ -0,0 +1,381 @@
+package couchdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// LocalNode is the node name alias which refers to the node receiving the request.
+const LocalNode = "_local"
+
+// NodeVersions represents the versions of runtime components reported by _node/{node}/_versions.
+type NodeVersions struct {
+	JavaScriptEngine struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	} `json:"javascript_engine"`
+	Erlang struct {
+		Version         string   `json:"version"`
+		SupportedHashes []string `json:"supported_hashes"`
+	} `json:"erlang"`
+	CollationDriver struct {
+		Name            string `json:"name"`
+		LibraryVersion  string `json:"library_version"`
+		CollatorVersion string `json:"collator_version"`
+	} `json:"collation_driver"`
+}
+
+// NodeSystem represents the Erlang VM statistics reported by _node/{node}/_system.
+type NodeSystem struct {
+	Uptime                  int64                  `json:"uptime"`
+	Memory                  map[string]int64       `json:"memory"`
+	RunQueue                int64                  `json:"run_queue"`
+	EtsTableCount           int64                  `json:"ets_table_count"`
+	ContextSwitches         int64                  `json:"context_switches"`
+	Reductions              int64                  `json:"reductions"`
+	GarbageCollectionCount  int64                  `json:"garbage_collection_count"`
+	WordsReclaimed          int64                  `json:"words_reclaimed"`
+	IOInput                 int64                  `json:"io_input"`
+	IOOutput                int64                  `json:"io_output"`
+	OSProcCount             int64                  `json:"os_proc_count"`
+	StaleProcCount          int64                  `json:"stale_proc_count"`
+	ProcessCount            int64                  `json:"process_count"`
+	ProcessLimit            int64                  `json:"process_limit"`
+	InternalReplicationJobs int64                  `json:"internal_replication_jobs"`
+	MessageQueues           map[string]interface{} `json:"message_queues"`
+	Distribution            map[string]interface{} `json:"distribution"`
+}
+
+// Histogram represents the value of a histogram metric.
+type Histogram struct {
+	N                 int64        `json:"n"`
+	Min               float64      `json:"min"`
+	Max               float64      `json:"max"`
+	ArithmeticMean    float64      `json:"arithmetic_mean"`
+	GeometricMean     float64      `json:"geometric_mean"`
+	HarmonicMean      float64      `json:"harmonic_mean"`
+	Median            float64      `json:"median"`
+	Variance          float64      `json:"variance"`
+	StandardDeviation float64      `json:"standard_deviation"`
+	Skewness          float64      `json:"skewness"`
+	Kurtosis          float64      `json:"kurtosis"`
+	Percentile        [][2]float64 `json:"percentile"`
+	Histogram         [][2]float64 `json:"histogram"`
+}
+
+// Metric represents a single statistic reported by _node/{node}/_stats.
+// Value is set for counters and gauges, Histogram for histograms.
+type Metric struct {
+	Type      string
+	Desc      string
+	Value     float64
+	Histogram *Histogram
+}
+
+// NodeStats maps slash-separated metric paths such as "couchdb/open_databases"
+// to the metrics of a node.
+type NodeStats map[string]Metric
+
+// Names returns the metric paths in sorted order.
+func (ns NodeStats) Names() []string {
+	names := make([]string, 0, len(ns))
+	for name := range ns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NodeErrors collects errors of an operation applied to several nodes, keyed by node name.
+type NodeErrors map[string]error
+
+func (ne NodeErrors) Error() string {
+	nodes := make([]string, 0, len(ne))
+	for node := range ne {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	msgs := make([]string, len(nodes))
+	for i, node := range nodes {
+		msgs[i] = fmt.Sprintf("%s: %v", node, ne[node])
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// eachClusterNode calls fn on every node in cluster_nodes of Membership,
+// errors are collected into NodeErrors.
+func (s *Server) eachClusterNode(fn func(node string) error) error {
+	_, clusterNodes, err := s.Membership()
+	if err != nil {
+		return err
+	}
+	errs := NodeErrors{}
+	for _, node := range clusterNodes {
+		if err = fn(node); err != nil {
+			errs[node] = err
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ConfigValue returns the value of key in section of the node configuration.
+func (s *Server) ConfigValue(node, section, key string) (string, error) {
+	_, data, err := s.resource.GetJSON(fmt.Sprintf("_node/%s/_config/%s/%s", node, section, key), nil, nil)
+	if err != nil {
+		return "", err
+	}
+	var value string
+	err = json.Unmarshal(data, &value)
+	if err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+// SetConfig sets the value of key in section of the node configuration,
+// returns the old value.
+func (s *Server) SetConfig(node, section, key, value string) (string, error) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	_, data, err := s.resource.Put(fmt.Sprintf("_node/%s/_config/%s/%s", node, section, key), nil, body, nil)
+	if err != nil {
+		return "", err
+	}
+	var old string
+	json.Unmarshal(data, &old)
+	return old, nil
+}
+
+// DeleteConfig deletes key in section of the node configuration, returns the old value.
+func (s *Server) DeleteConfig(node, section, key string) (string, error) {
+	_, data, err := s.resource.DeleteJSON(fmt.Sprintf("_node/%s/_config/%s/%s", node, section, key), nil, nil)
+	if err != nil {
+		return "", err
+	}
+	var old string
+	json.Unmarshal(data, &old)
+	return old, nil
+}
+
+// Admins returns the server admins of the node, mapping names to password hashes.
+func (s *Server) Admins(node string) (map[string]string, error) {
+	_, data, err := s.resource.GetJSON(fmt.Sprintf("_node/%s/_config/admins", node), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var admins map[string]string
+	err = json.Unmarshal(data, &admins)
+	if err != nil {
+		return nil, err
+	}
+	return admins, nil
+}
+
+// AddAdmin creates or updates a server admin on the node.
+func (s *Server) AddAdmin(node, name, password string) error {
+	_, err := s.SetConfig(node, "admins", name, password)
+	return err
+}
+
+// AddAdminAllNodes creates or updates a server admin on every cluster node.
+// Server admins are stored per node, so they must be created on all of them.
+func (s *Server) AddAdminAllNodes(name, password string) error {
+	return s.eachClusterNode(func(node string) error {
+		return s.AddAdmin(node, name, password)
+	})
+}
+
+// RemoveAdmin removes a server admin from the node.
+func (s *Server) RemoveAdmin(node, name string) error {
+	_, err := s.DeleteConfig(node, "admins", name)
+	return err
+}
+
+// RemoveAdminAllNodes removes a server admin from every cluster node.
+func (s *Server) RemoveAdminAllNodes(name string) error {
+	return s.eachClusterNode(func(node string) error {
+		return s.RemoveAdmin(node, name)
+	})
+}
+
+// SetMaintenanceMode turns maintenance mode of the node on or off. A node in
+// maintenance mode does not respond to clustered requests and reports 404 on /_up.
+func (s *Server) SetMaintenanceMode(node string, on bool) error {
+	_, err := s.SetConfig(node, "couchdb", "maintenance_mode", fmt.Sprintf("%t", on))
+	return err
+}
+
+// SetMaintenanceModeAllNodes turns maintenance mode of every cluster node on or off.
+func (s *Server) SetMaintenanceModeAllNodes(on bool) error {
+	return s.eachClusterNode(func(node string) error {
+		return s.SetMaintenanceMode(node, on)
+	})
+}
+
+// NodeVersions returns the versions of runtime components running on the node.
+func (s *Server) NodeVersions(node string) (*NodeVersions, error) {
+	_, data, err := s.resource.GetJSON(fmt.Sprintf("_node/%s/_versions", node), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	versions := &NodeVersions{}
+	err = json.Unmarshal(data, versions)
+	if err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// AllNodeVersions returns the versions of runtime components of every cluster node.
+func (s *Server) AllNodeVersions() (map[string]*NodeVersions, error) {
+	all := map[string]*NodeVersions{}
+	err := s.eachClusterNode(func(node string) error {
+		versions, err := s.NodeVersions(node)
+		if err != nil {
+			return err
+		}
+		all[node] = versions
+		return nil
+	})
+	return all, err
+}
+
+// NodeSystem returns the Erlang VM statistics of the node.
+func (s *Server) NodeSystem(node string) (*NodeSystem, error) {
+	_, data, err := s.resource.GetJSON(fmt.Sprintf("_node/%s/_system", node), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	system := &NodeSystem{}
+	err = json.Unmarshal(data, system)
+	if err != nil {
+		return nil, err
+	}
+	return system, nil
+}
+
+// AllNodeSystem returns the Erlang VM statistics of every cluster node.
+func (s *Server) AllNodeSystem() (map[string]*NodeSystem, error) {
+	all := map[string]*NodeSystem{}
+	err := s.eachClusterNode(func(node string) error {
+		system, err := s.NodeSystem(node)
+		if err != nil {
+			return err
+		}
+		all[node] = system
+		return nil
+	})
+	return all, err
+}
+
+// NodeStats returns the statistics of the node as typed metrics.
+func (s *Server) NodeStats(node string) (NodeStats, error) {
+	_, data, err := s.resource.GetJSON(fmt.Sprintf("_node/%s/_stats", node), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseNodeStats(data)
+}
+
+// NodeStatsEntry returns the statistics of the node under entry, a group such as
+// "couchdb" or a single metric such as "couchdb/open_databases", as typed metrics
+// keyed by their full paths.
+func (s *Server) NodeStatsEntry(node, entry string) (NodeStats, error) {
+	entry = strings.Trim(entry, "/")
+	if entry == "" {
+		return s.NodeStats(node)
+	}
+	_, data, err := s.resource.GetJSON(fmt.Sprintf("_node/%s/_stats/%s", node, entry), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := NodeStats{}
+	err = flattenStats(stats, "", map[string]json.RawMessage{entry: data})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// AllNodeStats returns the statistics of every cluster node as typed metrics.
+func (s *Server) AllNodeStats() (map[string]NodeStats, error) {
+	all := map[string]NodeStats{}
+	err := s.eachClusterNode(func(node string) error {
+		stats, err := s.NodeStats(node)
+		if err != nil {
+			return err
+		}
+		all[node] = stats
+		return nil
+	})
+	return all, err
+}
+
+func parseNodeStats(data []byte) (NodeStats, error) {
+	var tree map[string]json.RawMessage
+	err := json.Unmarshal(data, &tree)
+	if err != nil {
+		return nil, err
+	}
+	stats := NodeStats{}
+	err = flattenStats(stats, "", tree)
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// flattenStats walks the nested stats object, a metric is an object with both
+// "type" and "value", anything else is a group of metrics.
+func flattenStats(stats NodeStats, prefix string, tree map[string]json.RawMessage) error {
+	for name, raw := range tree {
+		path := name
+		if prefix != "" {
+			path = prefix + "/" + name
+		}
+
+		var obj map[string]json.RawMessage
+		if json.Unmarshal(raw, &obj) != nil {
+			continue
+		}
+
+		typeRaw, hasType := obj["type"]
+		valueRaw, hasValue := obj["value"]
+		if !hasType || !hasValue {
+			if err := flattenStats(stats, path, obj); err != nil {
+				return err
+			}
+			continue
+		}
+
+		metric := Metric{}
+		if json.Unmarshal(typeRaw, &metric.Type) != nil {
+			if err := flattenStats(stats, path, obj); err != nil {
+				return err
+			}
+			continue
+		}
+		if descRaw, ok := obj["desc"]; ok {
+			json.Unmarshal(descRaw, &metric.Desc)
+		}
+
+		if metric.Type == "histogram" {
+			metric.Histogram = &Histogram{}
+			if err := json.Unmarshal(valueRaw, metric.Histogram); err != nil {
+				return fmt.Errorf("metric %s: %v", path, err)
+			}
+		} else if err := json.Unmarshal(valueRaw, &metric.Value); err != nil {
+			return fmt.Errorf("metric %s: %v", path, err)
+		}
+		stats[path] = metric
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package couchdb
+
+import (
+	"testing"
+)
+
+func TestParseNodeStats(t *testing.T) {
+	data := []byte(`{
+		"couchdb": {
+			"open_databases": {"value": 3, "type": "counter", "desc": "number of open databases"},
+			"request_time": {
+				"value": {"min": 1, "max": 9, "arithmetic_mean": 4.5, "n": 10, "percentile": [[50, 4], [99, 9]]},
+				"type": "histogram",
+				"desc": "length of a request inside CouchDB without MochiWeb"
+			},
+			"httpd_request_methods": {
+				"GET": {"value": 42, "type": "counter", "desc": "number of HTTP GET requests"}
+			}
+		}
+	}`)
+	stats, err := parseNodeStats(data)
+	if err != nil {
+		t.Fatal("parse node stats error", err)
+	}
+
+	if len(stats) != 3 {
+		t.Errorf("stats length %d want 3", len(stats))
+	}
+
+	if stats["couchdb/open_databases"].Value != 3 {
+		t.Errorf("open_databases %v want 3", stats["couchdb/open_databases"].Value)
+	}
+
+	if stats["couchdb/httpd_request_methods/GET"].Value != 42 {
+		t.Errorf("GET requests %v want 42", stats["couchdb/httpd_request_methods/GET"].Value)
+	}
+
+	histogram := stats["couchdb/request_time"].Histogram
+	if histogram == nil {
+		t.Fatal("request_time histogram nil")
+	}
+	if histogram.N != 10 || histogram.Max != 9 || len(histogram.Percentile) != 2 {
+		t.Errorf("request_time histogram %+v unexpected", histogram)
+	}
+
+	names := stats.Names()
+	if names[0] != "couchdb/httpd_request_methods/GET" {
+		t.Errorf("first name %s want couchdb/httpd_request_methods/GET", names[0])
+	}
+}
+
+func TestNodeStats(t *testing.T) {
+	stats, err := server.NodeStats(LocalNode)
+	if err != nil {
+		t.Fatal("server node stats error", err)
+	}
+	if _, ok := stats["couchdb/open_databases"]; !ok {
+		t.Error("couchdb/open_databases not found")
+	}
+
+	metric, err := server.NodeStatsEntry(LocalNode, "couchdb/open_databases")
+	if err != nil {
+		t.Fatal("server node stats entry error", err)
+	}
+	if m, ok := metric["couchdb/open_databases"]; !ok || m.Type != "counter" || len(metric) != 1 {
+		t.Errorf("node stats entry %v want couchdb/open_databases counter", metric)
+	}
+
+	group, err := server.NodeStatsEntry(LocalNode, "couchdb")
+	if err != nil {
+		t.Fatal("server node stats entry error", err)
+	}
+	if _, ok := group["couchdb/open_databases"]; !ok || len(group) < 2 {
+		t.Errorf("node stats entry couchdb %v want couchdb/open_databases", group.Names())
+	}
+
+	all, err := server.AllNodeStats()
+	if err != nil {
+		t.Fatal("server all node stats error", err)
+	}
+	if len(all) == 0 {
+		t.Error("all node stats empty")
+	}
+}
+
+func TestNodeVersionsAndSystem(t *testing.T) {
+	versions, err := server.NodeVersions(LocalNode)
+	if err != nil {
+		t.Fatal("server node versions error", err)
+	}
+	if versions.Erlang.Version == "" {
+		t.Error("erlang version empty")
+	}
+
+	system, err := server.NodeSystem(LocalNode)
+	if err != nil {
+		t.Fatal("server node system error", err)
+	}
+	if system.ProcessCount <= 0 {
+		t.Errorf("process count %d want > 0", system.ProcessCount)
+	}
+}
+
+func TestAdminManagement(t *testing.T) {
+	err := server.AddAdmin(LocalNode, "golang-admin", "secret")
+	if err != nil {
+		t.Fatal("server add admin error", err)
+	}
+
+	admins, err := server.Admins(LocalNode)
+	if err != nil {
+		t.Fatal("server admins error", err)
+	}
+	if _, ok := admins["golang-admin"]; !ok {
+		t.Error("admin golang-admin not found")
+	}
+
+	err = server.RemoveAdmin(LocalNode, "golang-admin")
+	if err != nil {
+		t.Error("server remove admin error", err)
+	}
+
+	admins, err = server.Admins(LocalNode)
+	if err != nil {
+		t.Fatal("server admins error", err)
+	}
+	if _, ok := admins["golang-admin"]; ok {
+		t.Error("admin golang-admin not removed")
+	}
+}
+
+func TestMaintenanceMode(t *testing.T) {
+	err := server.SetMaintenanceMode(LocalNode, true)
+	if err != nil {
+		t.Fatal("server set maintenance mode error", err)
+	}
+
+	mode, err := server.ConfigValue(LocalNode, "couchdb", "maintenance_mode")
+	if err != nil {
+		t.Error("server config value error", err)
+	}
+	if mode != "true" {
+		t.Errorf("maintenance mode %s want true", mode)
+	}
+
+	err = server.SetMaintenanceMode(LocalNode, false)
+	if err != nil {
+		t.Error("server unset maintenance mode error", err)
+	}
+}
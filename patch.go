@@ -0,0 +1,355 @@
+package couchdb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrPatchTestFailed for JSON Patch test operations which do not match
+var ErrPatchTestFailed = errors.New("patch test failed")
+
+// PatchOp represents an operation of a JSON Patch(RFC 6902), Op is one of add,
+// remove, replace, move, copy and test. Path and From are JSON Pointers(RFC 6901).
+type PatchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// MarshalJSON encodes the operation, keeping a null value for add, replace and test.
+func (op PatchOp) MarshalJSON() ([]byte, error) {
+	fields := map[string]interface{}{"op": op.Op, "path": op.Path}
+	switch op.Op {
+	case "add", "replace", "test":
+		fields["value"] = op.Value
+	case "move", "copy":
+		fields["from"] = op.From
+	}
+	return json.Marshal(fields)
+}
+
+// Patch represents a JSON Patch(RFC 6902), a list of operations applied in order.
+type Patch []PatchOp
+
+// ApplyPatch applies patch to doc. The operations are applied atomically, doc is
+// left unchanged if one of them fails, e.g. with ErrPatchTestFailed.
+func ApplyPatch(doc map[string]interface{}, patch Patch) error {
+	var root interface{} = normalizeJSON(doc)
+	for _, op := range patch {
+		path, err := parsePointer(op.Path)
+		if err != nil {
+			return err
+		}
+
+		switch op.Op {
+		case "add":
+			root, err = pointerAdd(root, path, normalizeJSON(op.Value))
+		case "remove":
+			root, _, err = pointerRemove(root, path)
+		case "replace":
+			if len(path) == 0 {
+				// replacing the root replaces the whole document
+				root = normalizeJSON(op.Value)
+				break
+			}
+			_, err = pointerGet(root, path)
+			if err == nil {
+				root, _, err = pointerRemove(root, path)
+			}
+			if err == nil {
+				root, err = pointerAdd(root, path, normalizeJSON(op.Value))
+			}
+		case "move", "copy":
+			var from []string
+			from, err = parsePointer(op.From)
+			if err != nil {
+				return err
+			}
+			var value interface{}
+			if op.Op == "move" {
+				root, value, err = pointerRemove(root, from)
+			} else {
+				value, err = pointerGet(root, from)
+				value = normalizeJSON(value)
+			}
+			if err == nil {
+				root, err = pointerAdd(root, path, value)
+			}
+		case "test":
+			var value interface{}
+			value, err = pointerGet(root, path)
+			if err == nil && !reflect.DeepEqual(value, normalizeJSON(op.Value)) {
+				err = ErrPatchTestFailed
+			}
+		default:
+			err = fmt.Errorf("unknown patch operation %q", op.Op)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	result, ok := root.(map[string]interface{})
+	if !ok {
+		return errors.New("patched document is not an object")
+	}
+	for k := range doc {
+		delete(doc, k)
+	}
+	for k, v := range result {
+		doc[k] = v
+	}
+	return nil
+}
+
+// ApplyMergePatch applies the merge patch(RFC 7386) to doc: members of patch
+// replace those of doc, objects are merged recursively and null values remove
+// the members. A nil patch leaves doc unchanged.
+func ApplyMergePatch(doc map[string]interface{}, patch map[string]interface{}) {
+	members, _ := normalizeJSON(patch).(map[string]interface{})
+	for k, v := range members {
+		if v == nil {
+			delete(doc, k)
+			continue
+		}
+		if p, ok := v.(map[string]interface{}); ok {
+			target, ok := doc[k].(map[string]interface{})
+			if !ok {
+				target = map[string]interface{}{}
+			}
+			ApplyMergePatch(target, p)
+			doc[k] = target
+			continue
+		}
+		doc[k] = v
+	}
+}
+
+// Diff returns the JSON Patch turning the document a into b, for example two
+// revisions returned by Get. Arrays which differ are replaced as a whole, and
+// _rev is left out as it is managed by the database. A nil document is taken
+// as an empty one.
+func Diff(a, b map[string]interface{}) Patch {
+	patch := Patch{}
+	objA, _ := normalizeJSON(a).(map[string]interface{})
+	objB, _ := normalizeJSON(b).(map[string]interface{})
+	diffObjects("", objA, objB, &patch, true)
+	return patch
+}
+
+func diffObjects(path string, a, b map[string]interface{}, patch *Patch, top bool) {
+	keys := map[string]bool{}
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		if !top || k != "_rev" {
+			sorted = append(sorted, k)
+		}
+	}
+	sort.Strings(sorted)
+
+	for _, k := range sorted {
+		p := path + "/" + escapePointer(k)
+		va, inA := a[k]
+		vb, inB := b[k]
+		switch {
+		case !inB:
+			*patch = append(*patch, PatchOp{Op: "remove", Path: p})
+		case !inA:
+			*patch = append(*patch, PatchOp{Op: "add", Path: p, Value: vb})
+		default:
+			ma, okA := va.(map[string]interface{})
+			mb, okB := vb.(map[string]interface{})
+			if okA && okB {
+				diffObjects(p, ma, mb, patch, false)
+			} else if !reflect.DeepEqual(va, vb) {
+				*patch = append(*patch, PatchOp{Op: "replace", Path: p, Value: vb})
+			}
+		}
+	}
+}
+
+// Patch applies the JSON Patch to the document with the specified ID and saves
+// it, retrying on conflict like UpdateFunc, so that concurrent writers changing
+// other fields do not overwrite each other. Returns the new revision.
+func (d *Database) Patch(docid string, patch Patch, policy *RetryPolicy) (string, error) {
+	return d.UpdateFunc(docid, policy, func(doc map[string]interface{}) error {
+		return ApplyPatch(doc, patch)
+	})
+}
+
+// MergePatch applies the merge patch(RFC 7386) to the document with the specified
+// ID and saves it, retrying on conflict like UpdateFunc. Returns the new revision.
+func (d *Database) MergePatch(docid string, patch map[string]interface{}, policy *RetryPolicy) (string, error) {
+	return d.UpdateFunc(docid, policy, func(doc map[string]interface{}) error {
+		ApplyMergePatch(doc, patch)
+		return nil
+	})
+}
+
+// normalizeJSON returns a deep copy of v as decoded by encoding/json, so that
+// values compare equal whatever their original Go types.
+func normalizeJSON(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var result interface{}
+	if json.Unmarshal(data, &result) != nil {
+		return v
+	}
+	return result
+}
+
+func escapePointer(token string) string {
+	return strings.Replace(strings.Replace(token, "~", "~0", -1), "/", "~1", -1)
+}
+
+// parsePointer splits a JSON Pointer into its unescaped reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+func arrayIndex(token string, length int) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || index > length || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	return index, nil
+}
+
+func pointerGet(node interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			child, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("path member %q not found", token)
+			}
+			node = child
+		case []interface{}:
+			index, err := arrayIndex(token, len(n)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = n[index]
+		default:
+			return nil, fmt.Errorf("path member %q not found", token)
+		}
+	}
+	return node, nil
+}
+
+// pointerAdd returns node with value added at path.
+func pointerAdd(node interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	token := path[0]
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if len(path) == 1 {
+			n[token] = value
+			return n, nil
+		}
+		child, ok := n[token]
+		if !ok {
+			return nil, fmt.Errorf("path member %q not found", token)
+		}
+		child, err := pointerAdd(child, path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		n[token] = child
+		return n, nil
+	case []interface{}:
+		if len(path) == 1 {
+			if token == "-" {
+				return append(n, value), nil
+			}
+			index, err := arrayIndex(token, len(n))
+			if err != nil {
+				return nil, err
+			}
+			n = append(n, nil)
+			copy(n[index+1:], n[index:])
+			n[index] = value
+			return n, nil
+		}
+		index, err := arrayIndex(token, len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+		child, err := pointerAdd(n[index], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		n[index] = child
+		return n, nil
+	}
+	return nil, fmt.Errorf("path member %q not found", token)
+}
+
+// pointerRemove returns node with the value at path removed, along with the value.
+func pointerRemove(node interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, errors.New("cannot remove the whole document")
+	}
+	token := path[0]
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[token]
+		if !ok {
+			return nil, nil, fmt.Errorf("path member %q not found", token)
+		}
+		if len(path) == 1 {
+			delete(n, token)
+			return n, child, nil
+		}
+		child, removed, err := pointerRemove(child, path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		n[token] = child
+		return n, removed, nil
+	case []interface{}:
+		index, err := arrayIndex(token, len(n)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(path) == 1 {
+			removed := n[index]
+			return append(n[:index], n[index+1:]...), removed, nil
+		}
+		child, removed, err := pointerRemove(n[index], path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		n[index] = child
+		return n, removed, nil
+	}
+	return nil, nil, fmt.Errorf("path member %q not found", token)
+}
@@ -0,0 +1,147 @@
+package couchdb
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func decodeJSON(t *testing.T, s string) map[string]interface{} {
+	var v map[string]interface{}
+	err := json.Unmarshal([]byte(s), &v)
+	if err != nil {
+		t.Fatal("decode json error", err)
+	}
+	return v
+}
+
+func TestApplyPatch(t *testing.T) {
+	tests := []struct {
+		doc, patch, want string
+	}{
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"foo":"bar","baz":"qux"}`},
+		{`{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":"baz"}]`, `{"foo":["bar","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{`{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{`{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{`{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{`{"foo":{"a":1}}`, `[{"op":"copy","from":"/foo","path":"/bar"}]`, `{"foo":{"a":1},"bar":{"a":1}}`},
+		{`{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`, `{"baz":"qux","foo":["a",2,"c"]}`},
+		{`{"/":9,"~1":10}`, `[{"op":"replace","path":"/~1","value":1},{"op":"remove","path":"/~01"}]`, `{"/":1}`},
+		{`{"foo":"bar"}`, `[{"op":"replace","path":"","value":{"baz":"qux"}}]`, `{"baz":"qux"}`},
+	}
+
+	for _, test := range tests {
+		doc := decodeJSON(t, test.doc)
+		var patch Patch
+		err := json.Unmarshal([]byte(test.patch), &patch)
+		if err != nil {
+			t.Fatal("decode patch error", err)
+		}
+		err = ApplyPatch(doc, patch)
+		if err != nil {
+			t.Errorf("apply patch %s error %v", test.patch, err)
+			continue
+		}
+		if want := decodeJSON(t, test.want); !reflect.DeepEqual(doc, want) {
+			t.Errorf("apply patch %s = %v want %v", test.patch, doc, want)
+		}
+	}
+}
+
+func TestApplyPatchAtomic(t *testing.T) {
+	doc := map[string]interface{}{"baz": "qux"}
+	err := ApplyPatch(doc, Patch{
+		{Op: "add", Path: "/foo", Value: 1},
+		{Op: "test", Path: "/baz", Value: "bar"},
+	})
+	if err != ErrPatchTestFailed {
+		t.Errorf("apply patch error %v want %v", err, ErrPatchTestFailed)
+	}
+	if _, ok := doc["foo"]; ok {
+		t.Errorf("failed patch changed doc %v", doc)
+	}
+
+	err = ApplyPatch(doc, Patch{{Op: "remove", Path: "/missing"}})
+	if err == nil {
+		t.Error("remove missing member ok")
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	doc := decodeJSON(t, `{"title":"Goodbye!","author":{"givenName":"John","familyName":"Doe"},"tags":["example","sample"],"content":"This will be unchanged"}`)
+	patch := decodeJSON(t, `{"title":"Hello!","phoneNumber":"+01-123-456-7890","author":{"familyName":null},"tags":["example"]}`)
+	want := decodeJSON(t, `{"title":"Hello!","author":{"givenName":"John"},"tags":["example"],"content":"This will be unchanged","phoneNumber":"+01-123-456-7890"}`)
+
+	ApplyMergePatch(doc, patch)
+	if !reflect.DeepEqual(doc, want) {
+		t.Errorf("apply merge patch = %v want %v", doc, want)
+	}
+
+	ApplyMergePatch(doc, nil)
+	if !reflect.DeepEqual(doc, want) {
+		t.Errorf("apply nil merge patch = %v want %v", doc, want)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := decodeJSON(t, `{"_id":"x","_rev":"1-a","name":"old","nested":{"keep":1,"drop":2},"list":[1,2]}`)
+	b := decodeJSON(t, `{"_id":"x","_rev":"2-b","name":"new","nested":{"keep":1,"add":3},"list":[1,2,3]}`)
+
+	patch := Diff(a, b)
+	want := Patch{
+		{Op: "replace", Path: "/list", Value: []interface{}{float64(1), float64(2), float64(3)}},
+		{Op: "replace", Path: "/name", Value: "new"},
+		{Op: "add", Path: "/nested/add", Value: float64(3)},
+		{Op: "remove", Path: "/nested/drop"},
+	}
+	if !reflect.DeepEqual(patch, want) {
+		t.Errorf("diff = %v want %v", patch, want)
+	}
+
+	err := ApplyPatch(a, patch)
+	if err != nil {
+		t.Fatal("apply patch error", err)
+	}
+	a["_rev"] = b["_rev"]
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("patched doc %v want %v", a, b)
+	}
+
+	doc := decodeJSON(t, `{"name":"new"}`)
+	patch = Diff(nil, doc)
+	if want := (Patch{{Op: "add", Path: "/name", Value: "new"}}); !reflect.DeepEqual(patch, want) {
+		t.Errorf("diff from nil = %v want %v", patch, want)
+	}
+	patch = Diff(doc, nil)
+	if want := (Patch{{Op: "remove", Path: "/name"}}); !reflect.DeepEqual(patch, want) {
+		t.Errorf("diff to nil = %v want %v", patch, want)
+	}
+}
+
+func TestPatchDoc(t *testing.T) {
+	doc := map[string]interface{}{"_id": "patched", "a": 1, "b": 1}
+	_, _, err := testsDB.Save(doc, nil)
+	if err != nil {
+		t.Fatal("db save error", err)
+	}
+
+	_, err = testsDB.Patch("patched", Patch{{Op: "replace", Path: "/a", Value: 2}}, nil)
+	if err != nil {
+		t.Fatal("db patch error", err)
+	}
+	_, err = testsDB.MergePatch("patched", map[string]interface{}{"b": nil, "c": 3}, nil)
+	if err != nil {
+		t.Fatal("db merge patch error", err)
+	}
+
+	got, err := testsDB.Get("patched", nil)
+	if err != nil {
+		t.Fatal("db get error", err)
+	}
+	if _, ok := got["b"]; ok || got["a"].(float64) != 2 || got["c"].(float64) != 3 {
+		t.Errorf("patched doc %v want a 2 c 3 and no b", got)
+	}
+}
@@ -0,0 +1,100 @@
+package couchdb
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+const (
+	// purgeBatchDocs and purgeBatchRevs are the default maximum numbers of
+	// documents and revisions CouchDB accepts in one _purge request
+	purgeBatchDocs = 100
+	purgeBatchRevs = 1000
+)
+
+// PurgeResult represents the result of Database.PurgeRevs. Purged maps document
+// IDs to the revisions purged, Unpurged those requested but not purged, e.g.
+// because they did not exist. PurgeSeq is the purge sequence after the last
+// request, it is empty on CouchDB 2.3 and later which do not return it.
+type PurgeResult struct {
+	PurgeSeq Sequence
+	Purged   map[string][]string
+	Unpurged map[string][]string
+}
+
+// PurgeRevs removes completely the given revisions, mapped by document ID, from
+// the database, leaving no tombstones to replicate. Large inputs are split into
+// several requests within the limits of CouchDB. If a request fails the result
+// of the ones done before is returned along with the error, the revisions not
+// purged yet being in Unpurged.
+func (d *Database) PurgeRevs(revs map[string][]string) (*PurgeResult, error) {
+	result := &PurgeResult{Purged: map[string][]string{}, Unpurged: map[string][]string{}}
+	err := eachRevsBatch(revs, purgeBatchDocs, purgeBatchRevs, func(batch map[string][]string) error {
+		_, data, err := d.resource.PostJSON("_purge", nil, revsBody(batch), nil)
+		if err != nil {
+			return err
+		}
+
+		var response struct {
+			PurgeSeq Sequence            `json:"purge_seq"`
+			Purged   map[string][]string `json:"purged"`
+		}
+		err = json.Unmarshal(data, &response)
+		if err != nil {
+			return err
+		}
+		result.PurgeSeq = response.PurgeSeq
+		for id, purged := range response.Purged {
+			result.Purged[id] = append(result.Purged[id], purged...)
+		}
+		return nil
+	})
+
+	for id, docRevs := range revs {
+		purged := map[string]bool{}
+		for _, rev := range result.Purged[id] {
+			purged[rev] = true
+		}
+		for _, rev := range docRevs {
+			if !purged[rev] {
+				result.Unpurged[id] = append(result.Unpurged[id], rev)
+			}
+		}
+	}
+	return result, err
+}
+
+// PurgeSeq returns the current purge sequence of the database.
+func (d *Database) PurgeSeq() (Sequence, error) {
+	_, data, err := d.resource.GetJSON("", nil, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var info struct {
+		PurgeSeq Sequence `json:"purge_seq"`
+	}
+	err = json.Unmarshal(data, &info)
+	if err != nil {
+		return "", err
+	}
+	return info.PurgeSeq, nil
+}
+
+// GetPurgedInfosLimit gets the maximum number of purges tracked by the database,
+// available since CouchDB 2.3.
+func (d *Database) GetPurgedInfosLimit() (int, error) {
+	_, data, err := d.resource.Get("_purged_infos_limit", nil, nil)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
+// SetPurgedInfosLimit sets the maximum number of purges tracked by the database,
+// available since CouchDB 2.3.
+func (d *Database) SetPurgedInfosLimit(limit int) error {
+	_, _, err := d.resource.Put("_purged_infos_limit", nil, []byte(strconv.Itoa(limit)), nil)
+	return err
+}
@@ -0,0 +1,113 @@
+package couchdb
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestPurgeRevs(t *testing.T) {
+	doc := map[string]interface{}{"_id": "purge-revs"}
+	_, rev, err := testsDB.Save(doc, nil)
+	if err != nil {
+		t.Fatal("db save error", err)
+	}
+
+	result, err := testsDB.PurgeRevs(map[string][]string{
+		"purge-revs":    {rev},
+		"purge-missing": {"1-missing"},
+	})
+	if err != nil {
+		t.Fatal("db purge revs error", err)
+	}
+	if !reflect.DeepEqual(result.Purged["purge-revs"], []string{rev}) {
+		t.Errorf("purged %v want %s", result.Purged, rev)
+	}
+	if !reflect.DeepEqual(result.Unpurged["purge-missing"], []string{"1-missing"}) {
+		t.Errorf("unpurged %v want 1-missing", result.Unpurged)
+	}
+
+	err = testsDB.Contains("purge-revs")
+	if err != ErrNotFound {
+		t.Errorf("db contains purged doc error %v want %v", err, ErrNotFound)
+	}
+
+	_, err = testsDB.Purge([]map[string]interface{}{{"_id": "purge-norev"}})
+	if err == nil {
+		t.Error("db purge without _rev ok")
+	}
+}
+
+func TestPurgedInfosLimit(t *testing.T) {
+	err := testsDB.SetPurgedInfosLimit(2000)
+	if err != nil {
+		t.Fatal("db set purged infos limit error", err)
+	}
+	limit, err := testsDB.GetPurgedInfosLimit()
+	if err != nil {
+		t.Fatal("db get purged infos limit error", err)
+	}
+	if limit != 2000 {
+		t.Errorf("purged infos limit %d want 2000", limit)
+	}
+}
+
+func TestPurgeBatches(t *testing.T) {
+	revs := map[string][]string{}
+	for i := 0; i < purgeBatchDocs+1; i++ {
+		revs["doc"+strconv.Itoa(i)] = []string{"1-a"}
+	}
+
+	batches := 0
+	err := eachRevsBatch(revs, purgeBatchDocs, purgeBatchRevs, func(batch map[string][]string) error {
+		if len(batch) > purgeBatchDocs {
+			t.Errorf("batch docs %d exceeds %d", len(batch), purgeBatchDocs)
+		}
+		batches++
+		return nil
+	})
+	if err != nil {
+		t.Fatal("each revs batch error", err)
+	}
+	if batches != 2 {
+		t.Errorf("batches %d want 2", batches)
+	}
+}
+
+func TestPurgeRevsPartial(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests > 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{"error":"unknown_error","reason":"failed"}`)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"purge_seq":null,"purged":{"doc0":["1-a"]}}`)
+	}))
+	defer ts.Close()
+
+	db, err := NewDatabase(ts.URL + "/purge-partial")
+	if err != nil {
+		t.Fatal("new database error", err)
+	}
+	revs := map[string][]string{}
+	for i := 0; i < purgeBatchDocs+1; i++ {
+		revs["doc"+strconv.Itoa(i)] = []string{"1-a"}
+	}
+
+	result, err := db.PurgeRevs(revs)
+	if err == nil {
+		t.Fatal("db purge revs ok want error")
+	}
+	if result == nil || !reflect.DeepEqual(result.Purged["doc0"], []string{"1-a"}) {
+		t.Fatalf("purged %v want doc0 purged", result)
+	}
+	if len(result.Unpurged) != len(revs)-1 {
+		t.Errorf("unpurged %d want %d", len(result.Unpurged), len(revs)-1)
+	}
+}
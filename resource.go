@@ -2,6 +2,7 @@ package couchdb
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"errors"
@@ -68,6 +69,7 @@ func init() {
 type Resource struct {
 	header http.Header
 	base   *url.URL
+	cache  *ETagCache
 }
 
 // NewResource returns a newly-created Resource instance
@@ -115,6 +117,7 @@ func (r *Resource) NewResourceWithURL(resStr string) (*Resource, error) {
 	return &Resource{
 		header: r.header,
 		base:   u,
+		cache:  r.cache,
 	}, nil
 }
 
@@ -133,7 +136,7 @@ func (r *Resource) Get(path string, header http.Header, params url.Values) (http
 	if err != nil {
 		return nil, nil, err
 	}
-	return request(http.MethodGet, u, header, nil, params)
+	return r.cachedGet(context.Background(), u, header, params)
 }
 
 // Post is a wrapper around http.Post
@@ -169,7 +172,7 @@ func (r *Resource) GetJSON(path string, header http.Header, params url.Values) (
 	if err != nil {
 		return nil, nil, err
 	}
-	return request(http.MethodGet, u, header, nil, params)
+	return r.cachedGet(context.Background(), u, header, params)
 }
 
 // PostJSON issues a POST to the specified URL, with data returned as json
@@ -220,19 +223,82 @@ func checkHTTPStatusError(status int) error {
 	return err
 }
 
+// getContext issues a GET to the specified URL which is canceled along with ctx,
+// bypassing the cache as it serves feeds and probes which must reach the server.
+func (r *Resource) getContext(ctx context.Context, path string, header http.Header, params url.Values) (http.Header, []byte, error) {
+	u, err := combine(r.base, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return requestContext(ctx, http.MethodGet, u, header, nil, params)
+}
+
+// headContext issues a HEAD to the specified URL which is canceled along with ctx
+func (r *Resource) headContext(ctx context.Context, path string, header http.Header, params url.Values) (http.Header, []byte, error) {
+	u, err := combine(r.base, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return requestContext(ctx, http.MethodHead, u, header, nil, params)
+}
+
 // helper function to make real request
 func request(method string, u *url.URL, header http.Header, body io.Reader, params url.Values) (http.Header, []byte, error) {
+	return requestContext(context.Background(), method, u, header, body, params)
+}
+
+// requestContext makes real request which is canceled along with ctx
+func requestContext(ctx context.Context, method string, u *url.URL, header http.Header, body io.Reader, params url.Values) (http.Header, []byte, error) {
+	rsp, err := doRequest(ctx, method, u, header, body, -1, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rsp.Body.Close()
+	data, err := ioutil.ReadAll(rsp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return rsp.Header, data, checkHTTPStatusError(rsp.StatusCode)
+}
+
+// stream issues a request to the specified URL and returns the response with
+// its body unread, the caller must close it. length is the length of body, -1 if unknown.
+func (r *Resource) stream(ctx context.Context, method, path string, header http.Header, body io.Reader, length int64, params url.Values) (*http.Response, error) {
+	u, err := combine(r.base, path)
+	if err != nil {
+		return nil, err
+	}
+	rsp, err := doRequest(ctx, method, u, header, body, length, params)
+	if err != nil {
+		return nil, err
+	}
+	err = checkHTTPStatusError(rsp.StatusCode)
+	if err != nil {
+		rsp.Body.Close()
+		return nil, err
+	}
+	return rsp, nil
+}
+
+// doRequest sends the request and returns the response whatever its status is.
+func doRequest(ctx context.Context, method string, u *url.URL, header http.Header, body io.Reader, length int64, params url.Values) (*http.Response, error) {
 	method = strings.ToUpper(method)
 
-	u.RawQuery = params.Encode()
+	// copy the url so that resources shared by goroutines are never modified
+	reqURL := *u
+	reqURL.RawQuery = params.Encode()
 	var username, password string
-	if u.User != nil {
-		username = u.User.Username()
-		password, _ = u.User.Password()
+	if reqURL.User != nil {
+		username = reqURL.User.Username()
+		password, _ = reqURL.User.Password()
 	}
-	req, err := http.NewRequest(method, u.String(), body)
+	req, err := http.NewRequest(method, reqURL.String(), body)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
+	}
+	if body != nil && length >= 0 {
+		req.ContentLength = length
 	}
 
 	if len(username) > 0 && len(password) > 0 {
@@ -245,17 +311,7 @@ func request(method string, u *url.URL, header http.Header, body io.Reader, para
 	updateHeader(&req.Header, &header)
 	updateHeader(&req.Header, cookieAuthHeader)
 
-	rsp, err := httpClient.Do(req)
-	if err != nil {
-		return nil, nil, err
-	}
-	defer rsp.Body.Close()
-	data, err := ioutil.ReadAll(rsp.Body)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	return rsp.Header, data, checkHTTPStatusError(rsp.StatusCode)
+	return httpClient.Do(req.WithContext(ctx))
 }
 
 // setDefault sets the default value if key not existe in header
@@ -0,0 +1,209 @@
+package couchdb
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Statuses of the revisions in _revs_info.
+const (
+	RevAvailable = "available"
+	RevMissing   = "missing"
+	RevDeleted   = "deleted"
+)
+
+// RevInfo represents an entry of _revs_info, Status is one of RevAvailable,
+// RevMissing and RevDeleted.
+type RevInfo struct {
+	Rev    string `json:"rev"`
+	Status string `json:"status"`
+}
+
+// RevisionHistory represents the history of the winning revision of a document.
+// Info lists all the revisions latest first, Docs holds the bodies still available
+// in the same order and Missing the revisions whose bodies have been compacted away.
+type RevisionHistory struct {
+	Info    []RevInfo
+	Docs    []map[string]interface{}
+	Missing []string
+}
+
+// RevisionHistory returns the history of the winning revision of the document,
+// fetching all the bodies available in a single open_revs request.
+// options: optional query parameters applied to the bodies, e.g. attachments.
+func (d *Database) RevisionHistory(docid string, options url.Values) (*RevisionHistory, error) {
+	_, data, err := docResource(d.resource, docid).GetJSON("", nil, url.Values{"revs_info": []string{"true"}})
+	if err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		RevsInfo []RevInfo `json:"_revs_info"`
+	}
+	err = json.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &RevisionHistory{Info: doc.RevsInfo, Docs: []map[string]interface{}{}, Missing: []string{}}
+	revs := []string{}
+	for _, info := range doc.RevsInfo {
+		if info.Status == RevMissing {
+			history.Missing = append(history.Missing, info.Rev)
+		} else {
+			revs = append(revs, info.Rev)
+		}
+	}
+	if len(revs) == 0 {
+		return history, nil
+	}
+
+	openRevs, err := json.Marshal(revs)
+	if err != nil {
+		return nil, err
+	}
+	docs, missing, err := d.openRevs(docid, string(openRevs), options)
+	if err != nil {
+		return nil, err
+	}
+
+	byRev := map[string]map[string]interface{}{}
+	for _, doc := range docs {
+		rev, _ := doc["_rev"].(string)
+		byRev[rev] = doc
+	}
+	history.Missing = append(history.Missing, missing...)
+
+	for _, rev := range revs {
+		if doc, ok := byRev[rev]; ok {
+			history.Docs = append(history.Docs, doc)
+		}
+	}
+	return history, nil
+}
+
+// RevNode represents a revision in a RevTree. Deleted is only known for leaves.
+type RevNode struct {
+	Rev      string
+	Deleted  bool
+	Parent   *RevNode
+	Children []*RevNode
+}
+
+// Generation returns the generation number of the revision, the N in N-hash.
+func (n *RevNode) Generation() int {
+	return revGeneration(n.Rev)
+}
+
+// Leaf returns true if the revision has no children.
+func (n *RevNode) Leaf() bool {
+	return len(n.Children) == 0
+}
+
+// RevTree represents the revision tree of a document including its deleted and
+// conflicting branches. There may be several roots once the oldest revisions
+// are stemmed by the revs_limit of the database. Leaves are sorted the way
+// CouchDB picks the winner, which comes first.
+type RevTree struct {
+	Roots  []*RevNode
+	Leaves []*RevNode
+	Nodes  map[string]*RevNode
+	Winner *RevNode
+}
+
+// Conflicts returns the non-deleted leaves other than the winner.
+func (t *RevTree) Conflicts() []*RevNode {
+	conflicts := []*RevNode{}
+	for _, leaf := range t.Leaves {
+		if leaf != t.Winner && !leaf.Deleted {
+			conflicts = append(conflicts, leaf)
+		}
+	}
+	return conflicts
+}
+
+// RevisionTree returns the full revision tree of the document, built from the
+// histories of all its leaf revisions.
+func (d *Database) RevisionTree(docid string) (*RevTree, error) {
+	leaves, err := d.LeafRevisions(docid)
+	if err != nil {
+		return nil, err
+	}
+	if len(leaves) == 0 {
+		return nil, ErrNotFound
+	}
+	return buildRevTree(leaves), nil
+}
+
+func buildRevTree(leaves []map[string]interface{}) *RevTree {
+	tree := &RevTree{Nodes: map[string]*RevNode{}}
+	node := func(rev string) *RevNode {
+		n, ok := tree.Nodes[rev]
+		if !ok {
+			n = &RevNode{Rev: rev}
+			tree.Nodes[rev] = n
+		}
+		return n
+	}
+
+	for _, leaf := range leaves {
+		path := revisionPath(leaf)
+		if len(path) == 0 {
+			continue
+		}
+		n := node(path[0])
+		n.Deleted, _ = leaf["_deleted"].(bool)
+		for _, rev := range path[1:] {
+			parent := node(rev)
+			if n.Parent == nil {
+				n.Parent = parent
+				parent.Children = append(parent.Children, n)
+			}
+			n = parent
+		}
+	}
+
+	for _, n := range tree.Nodes {
+		sort.Slice(n.Children, func(i, j int) bool { return revLess(n.Children[i], n.Children[j]) })
+		if n.Parent == nil {
+			tree.Roots = append(tree.Roots, n)
+		}
+		if n.Leaf() {
+			tree.Leaves = append(tree.Leaves, n)
+		}
+	}
+	sort.Slice(tree.Roots, func(i, j int) bool { return revLess(tree.Roots[i], tree.Roots[j]) })
+
+	// the winner is the non-deleted leaf with the highest generation, then hash
+	sort.Slice(tree.Leaves, func(i, j int) bool {
+		a, b := tree.Leaves[i], tree.Leaves[j]
+		if a.Deleted != b.Deleted {
+			return !a.Deleted
+		}
+		return revLess(b, a)
+	})
+	if len(tree.Leaves) > 0 {
+		tree.Winner = tree.Leaves[0]
+	}
+	return tree
+}
+
+// revLess orders revisions by generation then hash.
+func revLess(a, b *RevNode) bool {
+	if a.Generation() != b.Generation() {
+		return a.Generation() < b.Generation()
+	}
+	return a.Rev < b.Rev
+}
+
+func revGeneration(rev string) int {
+	pos := strings.Index(rev, "-")
+	if pos < 0 {
+		return 0
+	}
+	gen, _ := strconv.Atoi(rev[:pos])
+	return gen
+}
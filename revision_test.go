@@ -0,0 +1,66 @@
+package couchdb
+
+import (
+	"testing"
+)
+
+func TestRevisionHistory(t *testing.T) {
+	doc := map[string]interface{}{"_id": "history", "count": 1}
+	_, oldRev, err := testsDB.Save(doc, nil)
+	if err != nil {
+		t.Fatal("db save error", err)
+	}
+	doc["count"] = 2
+	_, newRev, err := testsDB.Save(doc, nil)
+	if err != nil {
+		t.Fatal("db save error", err)
+	}
+
+	history, err := testsDB.RevisionHistory("history", nil)
+	if err != nil {
+		t.Fatal("db revision history error", err)
+	}
+	if len(history.Info) != 2 || history.Info[0].Rev != newRev || history.Info[1].Rev != oldRev {
+		t.Errorf("revision history info %v want %s and %s", history.Info, newRev, oldRev)
+	}
+	if len(history.Docs)+len(history.Missing) != 2 {
+		t.Errorf("revision history docs %d missing %v want 2 in total", len(history.Docs), history.Missing)
+	}
+
+	tree, err := testsDB.RevisionTree("history")
+	if err != nil {
+		t.Fatal("db revision tree error", err)
+	}
+	if tree.Winner.Rev != newRev || tree.Winner.Parent.Rev != oldRev {
+		t.Errorf("revision tree winner %v want %s", tree.Winner, newRev)
+	}
+}
+
+func TestBuildRevTree(t *testing.T) {
+	leaves := []map[string]interface{}{
+		{"_rev": "3-c", "_revisions": map[string]interface{}{"start": float64(3), "ids": []interface{}{"c", "b", "a"}}},
+		{"_rev": "3-d", "_deleted": true, "_revisions": map[string]interface{}{"start": float64(3), "ids": []interface{}{"d", "b", "a"}}},
+		{"_rev": "2-e", "_revisions": map[string]interface{}{"start": float64(2), "ids": []interface{}{"e", "a"}}},
+	}
+	tree := buildRevTree(leaves)
+
+	if len(tree.Roots) != 1 || tree.Roots[0].Rev != "1-a" {
+		t.Errorf("roots %v want 1-a", tree.Roots)
+	}
+	if len(tree.Nodes) != 5 {
+		t.Errorf("nodes %d want 5", len(tree.Nodes))
+	}
+	if tree.Winner.Rev != "3-c" {
+		t.Errorf("winner %s want 3-c", tree.Winner.Rev)
+	}
+	if len(tree.Leaves) != 3 || !tree.Leaves[2].Deleted {
+		t.Errorf("leaves %v want deleted 3-d last", tree.Leaves)
+	}
+	conflicts := tree.Conflicts()
+	if len(conflicts) != 1 || conflicts[0].Rev != "2-e" {
+		t.Errorf("conflicts %v want 2-e", conflicts)
+	}
+	if b := tree.Nodes["2-b"]; len(b.Children) != 2 || b.Parent.Rev != "1-a" {
+		t.Errorf("node 2-b %v want 2 children and parent 1-a", b)
+	}
+}
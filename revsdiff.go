@@ -0,0 +1,126 @@
+package couchdb
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// revsDiffBatch is the maximum number of revisions sent in one request of
+// RevsDiff and MissingRevs, larger inputs are split into several requests.
+const revsDiffBatch = 1000
+
+// RevsDiffResult represents the result of _revs_diff for one document.
+// PossibleAncestors holds the known revisions which may be ancestors of the
+// missing ones, so that only the newer parts of their history need to be sent.
+type RevsDiffResult struct {
+	Missing           []string `json:"missing"`
+	PossibleAncestors []string `json:"possible_ancestors"`
+}
+
+// RevsDiff returns which of the given revisions, mapped by document ID, are missing
+// from the database. Documents with no missing revisions are left out of the result.
+func (d *Database) RevsDiff(revs map[string][]string) (map[string]RevsDiffResult, error) {
+	results := map[string]RevsDiffResult{}
+	err := eachRevsBatch(revs, 0, revsDiffBatch, func(batch map[string][]string) error {
+		_, data, err := d.resource.PostJSON("_revs_diff", nil, revsBody(batch), nil)
+		if err != nil {
+			return err
+		}
+
+		var response map[string]RevsDiffResult
+		err = json.Unmarshal(data, &response)
+		if err != nil {
+			return err
+		}
+		// a document may span several batches
+		for id, result := range response {
+			merged := results[id]
+			merged.Missing = append(merged.Missing, result.Missing...)
+			merged.PossibleAncestors = append(merged.PossibleAncestors, result.PossibleAncestors...)
+			results[id] = merged
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// MissingRevs returns which of the given revisions, mapped by document ID, are missing
+// from the database. Documents with no missing revisions are left out of the result.
+func (d *Database) MissingRevs(revs map[string][]string) (map[string][]string, error) {
+	results := map[string][]string{}
+	err := eachRevsBatch(revs, 0, revsDiffBatch, func(batch map[string][]string) error {
+		_, data, err := d.resource.PostJSON("_missing_revs", nil, revsBody(batch), nil)
+		if err != nil {
+			return err
+		}
+
+		var response struct {
+			MissingRevs map[string][]string `json:"missing_revs"`
+		}
+		err = json.Unmarshal(data, &response)
+		if err != nil {
+			return err
+		}
+		for id, missing := range response.MissingRevs {
+			results[id] = append(results[id], missing...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// eachRevsBatch calls fn with batches of revs holding up to maxRevs revisions
+// of up to maxDocs documents, no limit on documents if maxDocs is not positive.
+// The revisions of a document are split only if they exceed maxRevs.
+func eachRevsBatch(revs map[string][]string, maxDocs, maxRevs int, fn func(map[string][]string) error) error {
+	ids := make([]string, 0, len(revs))
+	for id := range revs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	batch, size := map[string][]string{}, 0
+	flush := func() error {
+		if size == 0 {
+			return nil
+		}
+		err := fn(batch)
+		batch, size = map[string][]string{}, 0
+		return err
+	}
+
+	for _, id := range ids {
+		docRevs := revs[id]
+		if maxDocs > 0 && len(batch) == maxDocs {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		for len(docRevs) > 0 {
+			if size == maxRevs {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+			n := min(len(docRevs), maxRevs-size)
+			batch[id] = append(batch[id], docRevs[:n]...)
+			docRevs = docRevs[n:]
+			size += n
+		}
+	}
+	return flush()
+}
+
+func revsBody(revs map[string][]string) map[string]interface{} {
+	body := make(map[string]interface{}, len(revs))
+	for id, docRevs := range revs {
+		body[id] = docRevs
+	}
+	return body
+}
@@ -0,0 +1,65 @@
+package couchdb
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestRevsDiff(t *testing.T) {
+	doc := map[string]interface{}{"_id": "revsdiff"}
+	_, rev, err := testsDB.Save(doc, nil)
+	if err != nil {
+		t.Fatal("db save error", err)
+	}
+
+	revs := map[string][]string{
+		"revsdiff":         {rev, "2-missing"},
+		"revsdiff-missing": {"1-missing"},
+	}
+	diff, err := testsDB.RevsDiff(revs)
+	if err != nil {
+		t.Fatal("db revs diff error", err)
+	}
+	if !reflect.DeepEqual(diff["revsdiff"].Missing, []string{"2-missing"}) {
+		t.Errorf("revs diff %v want missing 2-missing", diff["revsdiff"])
+	}
+	if !reflect.DeepEqual(diff["revsdiff-missing"].Missing, []string{"1-missing"}) {
+		t.Errorf("revs diff %v want missing 1-missing", diff["revsdiff-missing"])
+	}
+
+	missing, err := testsDB.MissingRevs(revs)
+	if err != nil {
+		t.Fatal("db missing revs error", err)
+	}
+	if !reflect.DeepEqual(missing["revsdiff"], []string{"2-missing"}) {
+		t.Errorf("missing revs %v want 2-missing", missing["revsdiff"])
+	}
+}
+
+func TestEachRevsBatch(t *testing.T) {
+	revs := map[string][]string{"big": {}, "small": {"1-a", "1-b"}}
+	for i := 0; i < revsDiffBatch+10; i++ {
+		revs["big"] = append(revs["big"], strconv.Itoa(i)+"-x")
+	}
+
+	batches, total := 0, 0
+	err := eachRevsBatch(revs, 0, revsDiffBatch, func(batch map[string][]string) error {
+		size := 0
+		for _, r := range batch {
+			size += len(r)
+		}
+		if size > revsDiffBatch {
+			t.Errorf("batch size %d exceeds %d", size, revsDiffBatch)
+		}
+		batches++
+		total += size
+		return nil
+	})
+	if err != nil {
+		t.Fatal("each revs batch error", err)
+	}
+	if batches != 2 || total != revsDiffBatch+12 {
+		t.Errorf("batches %d total %d want 2 and %d", batches, total, revsDiffBatch+12)
+	}
+}
@@ -106,6 +106,7 @@ func (s *Server) DBs() ([]string, error) {
 }
 
 // Stats returns a JSON object containing the statistics for the running server.
+// Use NodeStatsEntry for typed metrics.
 func (s *Server) Stats(node, entry string) (map[string]interface{}, error) {
 	var stats map[string]interface{}
 	_, data, err := s.resource.GetJSON(fmt.Sprintf("_node/%s/_stats/%s", node, entry), nil, url.Values{})
@@ -0,0 +1,285 @@
+package couchdb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// States of a resharding job.
+const (
+	ReshardJobNew       = "new"
+	ReshardJobRunning   = "running"
+	ReshardJobStopped   = "stopped"
+	ReshardJobCompleted = "completed"
+	ReshardJobFailed    = "failed"
+)
+
+// ErrReshardTimeout for resharding jobs not completed in time.
+var ErrReshardTimeout = errors.New("timeout waiting for resharding jobs")
+
+// DocShard represents the shard range of a document and the nodes holding its replicas.
+type DocShard struct {
+	Range string   `json:"range"`
+	Nodes []string `json:"nodes"`
+}
+
+// Shards returns the shard ranges of the database mapped to the nodes holding their replicas.
+func (d *Database) Shards() (map[string][]string, error) {
+	_, data, err := d.resource.GetJSON("_shards", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Shards map[string][]string `json:"shards"`
+	}
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result.Shards, nil
+}
+
+// DocShard returns the shard range where the document with the specified ID lives
+// and the nodes holding its replicas. The document does not have to exist.
+func (d *Database) DocShard(docid string) (*DocShard, error) {
+	shardRes := docResource(docResource(d.resource, "_shards"), docid)
+	_, data, err := shardRes.GetJSON("", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	shard := &DocShard{}
+	err = json.Unmarshal(data, shard)
+	if err != nil {
+		return nil, err
+	}
+	return shard, nil
+}
+
+// ReshardSummary represents the summary of resharding on the cluster.
+type ReshardSummary struct {
+	State       string `json:"state"`
+	StateReason string `json:"state_reason"`
+	Completed   int    `json:"completed"`
+	Failed      int    `json:"failed"`
+	Running     int    `json:"running"`
+	Stopped     int    `json:"stopped"`
+	Total       int    `json:"total"`
+}
+
+// ReshardEvent represents an event in the history of a resharding job.
+type ReshardEvent struct {
+	Timestamp string `json:"timestamp"`
+	Type      string `json:"type"`
+	Detail    string `json:"detail"`
+}
+
+// ReshardJob represents a resharding job.
+type ReshardJob struct {
+	ID         string   `json:"id"`
+	Type       string   `json:"type"`
+	JobState   string   `json:"job_state"`
+	SplitState string   `json:"split_state"`
+	Node       string   `json:"node"`
+	Source     string   `json:"source"`
+	Target     []string `json:"target"`
+	StartTime  string   `json:"start_time"`
+	UpdateTime string   `json:"update_time"`
+	StateInfo  struct {
+		Reason string `json:"reason"`
+	} `json:"state_info"`
+	History []ReshardEvent `json:"history"`
+}
+
+// Done returns true if the job is completed or failed.
+func (j *ReshardJob) Done() bool {
+	return j.JobState == ReshardJobCompleted || j.JobState == ReshardJobFailed
+}
+
+// SplitRequest describes shards to split. DB is required, Node, Range and Shard
+// narrow down the shard copies to split, Shard excludes DB and Range.
+type SplitRequest struct {
+	DB    string
+	Node  string
+	Range string
+	Shard string
+}
+
+// ReshardSummary returns the summary of resharding on the cluster.
+func (s *Server) ReshardSummary() (*ReshardSummary, error) {
+	_, data, err := s.resource.GetJSON("_reshard", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	summary := &ReshardSummary{}
+	err = json.Unmarshal(data, summary)
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// ReshardState returns the cluster resharding state, "running" or "stopped", and the reason.
+func (s *Server) ReshardState() (string, string, error) {
+	_, data, err := s.resource.GetJSON("_reshard/state", nil, nil)
+	if err != nil {
+		return "", "", err
+	}
+	var result struct {
+		State  string `json:"state"`
+		Reason string `json:"reason"`
+	}
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return "", "", err
+	}
+	return result.State, result.Reason, nil
+}
+
+// SetReshardState starts or stops resharding on the cluster, state is "running" or "stopped".
+func (s *Server) SetReshardState(state, reason string) error {
+	body := map[string]interface{}{"state": state}
+	if reason != "" {
+		body["reason"] = reason
+	}
+	_, _, err := s.resource.PutJSON("_reshard/state", nil, body, nil)
+	return err
+}
+
+// ReshardJobs returns all the resharding jobs.
+func (s *Server) ReshardJobs() ([]ReshardJob, error) {
+	_, data, err := s.resource.GetJSON("_reshard/jobs", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Jobs []ReshardJob `json:"jobs"`
+	}
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result.Jobs, nil
+}
+
+// ReshardJob returns the resharding job with the specified ID.
+func (s *Server) ReshardJob(id string) (*ReshardJob, error) {
+	_, data, err := s.resource.GetJSON(fmt.Sprintf("_reshard/jobs/%s", id), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	job := &ReshardJob{}
+	err = json.Unmarshal(data, job)
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// SplitShards creates split jobs for the shards described by req, returns the IDs of the jobs created.
+func (s *Server) SplitShards(req SplitRequest) ([]string, error) {
+	body := map[string]interface{}{"type": "split"}
+	if req.DB != "" {
+		body["db"] = req.DB
+	}
+	if req.Node != "" {
+		body["node"] = req.Node
+	}
+	if req.Range != "" {
+		body["range"] = req.Range
+	}
+	if req.Shard != "" {
+		body["shard"] = req.Shard
+	}
+
+	_, data, err := s.resource.PostJSON("_reshard/jobs", nil, body, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []struct {
+		OK     bool   `json:"ok"`
+		ID     string `json:"id"`
+		Error  string `json:"error"`
+		Reason string `json:"reason"`
+	}
+	err = json.Unmarshal(data, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []string{}
+	for _, result := range results {
+		if !result.OK {
+			return ids, fmt.Errorf("%s: %s", result.Error, result.Reason)
+		}
+		ids = append(ids, result.ID)
+	}
+	return ids, nil
+}
+
+// StartReshardJob resumes the stopped resharding job.
+func (s *Server) StartReshardJob(id string) error {
+	return s.setReshardJobState(id, ReshardJobRunning, "")
+}
+
+// StopReshardJob stops the resharding job.
+func (s *Server) StopReshardJob(id, reason string) error {
+	return s.setReshardJobState(id, ReshardJobStopped, reason)
+}
+
+func (s *Server) setReshardJobState(id, state, reason string) error {
+	body := map[string]interface{}{"state": state}
+	if reason != "" {
+		body["reason"] = reason
+	}
+	_, _, err := s.resource.PutJSON(fmt.Sprintf("_reshard/jobs/%s/state", id), nil, body, nil)
+	return err
+}
+
+// DeleteReshardJob stops and removes the resharding job.
+func (s *Server) DeleteReshardJob(id string) error {
+	_, _, err := s.resource.DeleteJSON(fmt.Sprintf("_reshard/jobs/%s", id), nil, nil)
+	return err
+}
+
+// WaitReshardJobs polls the resharding jobs every interval until all of them
+// are completed or failed, returns ErrReshardTimeout if not done in timeout.
+// An error is returned if any of the jobs failed.
+func (s *Server) WaitReshardJobs(ids []string, interval, timeout time.Duration) ([]ReshardJob, error) {
+	deadline := time.Now().Add(timeout)
+	jobs := make([]ReshardJob, len(ids))
+	for {
+		done := true
+		for i, id := range ids {
+			if jobs[i].Done() {
+				continue
+			}
+			job, err := s.ReshardJob(id)
+			if err != nil {
+				return jobs, err
+			}
+			jobs[i] = *job
+			if !job.Done() {
+				done = false
+			}
+		}
+
+		if done {
+			break
+		}
+
+		if time.Now().Add(interval).After(deadline) {
+			return jobs, ErrReshardTimeout
+		}
+		time.Sleep(interval)
+	}
+
+	for _, job := range jobs {
+		if job.JobState == ReshardJobFailed {
+			return jobs, fmt.Errorf("resharding job %s failed: %s", job.ID, job.StateInfo.Reason)
+		}
+	}
+	return jobs, nil
+}
@@ -0,0 +1,52 @@
+package couchdb
+
+import (
+	"testing"
+)
+
+func TestShards(t *testing.T) {
+	shards, err := testsDB.Shards()
+	if err != nil {
+		t.Fatal("db shards error", err)
+	}
+	if len(shards) == 0 {
+		t.Fatal("db shards empty")
+	}
+
+	shard, err := testsDB.DocShard("foo")
+	if err != nil {
+		t.Fatal("db doc shard error", err)
+	}
+
+	nodes, ok := shards[shard.Range]
+	if !ok {
+		t.Fatalf("doc shard range %s not in %v", shard.Range, shards)
+	}
+
+	if len(nodes) != len(shard.Nodes) {
+		t.Errorf("doc shard nodes %v want %v", shard.Nodes, nodes)
+	}
+}
+
+func TestReshardSummary(t *testing.T) {
+	summary, err := server.ReshardSummary()
+	if err != nil {
+		t.Fatal("server reshard summary error", err)
+	}
+
+	state, _, err := server.ReshardState()
+	if err != nil {
+		t.Fatal("server reshard state error", err)
+	}
+	if state != summary.State {
+		t.Errorf("reshard state %s want %s", state, summary.State)
+	}
+
+	jobs, err := server.ReshardJobs()
+	if err != nil {
+		t.Fatal("server reshard jobs error", err)
+	}
+	if len(jobs) != summary.Total {
+		t.Errorf("reshard jobs %d want %d", len(jobs), summary.Total)
+	}
+}
@@ -0,0 +1,257 @@
+package couchdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// Sequence represents an update sequence of database. It is a number in CouchDB 1.x
+// and an opaque string since 2.0, so it can only be passed back as it is, e.g. as since.
+type Sequence string
+
+// UnmarshalJSON decodes both numeric and string sequences.
+func (s *Sequence) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*s = ""
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var str string
+		err := json.Unmarshal(data, &str)
+		if err != nil {
+			return err
+		}
+		*s = Sequence(str)
+		return nil
+	}
+	*s = Sequence(data)
+	return nil
+}
+
+// Attachment represents an entry of the _attachments of a document, either
+// a stub describing a stored attachment or inline Data to be stored.
+type Attachment struct {
+	ContentType   string `json:"content_type,omitempty"`
+	Data          []byte `json:"data,omitempty"`
+	Digest        string `json:"digest,omitempty"`
+	Length        int64  `json:"length,omitempty"`
+	RevPos        int    `json:"revpos,omitempty"`
+	Stub          bool   `json:"stub,omitempty"`
+	Follows       bool   `json:"follows,omitempty"`
+	Encoding      string `json:"encoding,omitempty"`
+	EncodedLength int64  `json:"encoded_length,omitempty"`
+}
+
+// Meta holds the special fields of a document. Embed it into a struct to have
+// them round-tripped by Collection, for example:
+//
+//	type User struct {
+//	  Name string `json:"name"`
+//	  Meta
+//	}
+//
+// Meta and Document must not be embedded into the same struct, their _id and _rev
+// fields would cancel each other out.
+type Meta struct {
+	ID          string                `json:"_id,omitempty"`
+	Rev         string                `json:"_rev,omitempty"`
+	Deleted     bool                  `json:"_deleted,omitempty"`
+	Attachments map[string]Attachment `json:"_attachments,omitempty"`
+	Conflicts   []string              `json:"_conflicts,omitempty"`
+}
+
+// TypedRow represents a row returned by database views with the document decoded into T.
+type TypedRow[T any] struct {
+	ID    string      `json:"id"`
+	Key   interface{} `json:"key"`
+	Value interface{} `json:"value"`
+	Doc   *T          `json:"doc"`
+	Error string      `json:"error"`
+}
+
+// ChangeRev represents a leaf revision in a change.
+type ChangeRev struct {
+	Rev string `json:"rev"`
+}
+
+// Change represents a row of the changes feed with the document decoded into T.
+type Change[T any] struct {
+	Seq     Sequence    `json:"seq"`
+	ID      string      `json:"id"`
+	Changes []ChangeRev `json:"changes"`
+	Deleted bool        `json:"deleted"`
+	Doc     *T          `json:"doc"`
+}
+
+// Collection provides access to the documents of a database decoded directly
+// into values of type T, which is usually a struct embedding Meta.
+type Collection[T any] struct {
+	db *Database
+}
+
+// NewCollection returns a Collection of documents of type T in db.
+func NewCollection[T any](db *Database) *Collection[T] {
+	return &Collection[T]{
+		db: db,
+	}
+}
+
+// DB returns the database of the collection.
+func (c *Collection[T]) DB() *Database {
+	return c.db
+}
+
+// Get returns the document with the specified ID.
+func (c *Collection[T]) Get(docid string, options url.Values) (*T, error) {
+	_, data, err := docResource(c.db.resource, docid).GetJSON("", nil, options)
+	if err != nil {
+		return nil, err
+	}
+	doc := new(T)
+	err = json.Unmarshal(data, doc)
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// docMeta extracts _id and _rev from the JSON encoding of a document.
+func docMeta(data []byte) (string, string, error) {
+	var meta struct {
+		ID  string `json:"_id"`
+		Rev string `json:"_rev"`
+	}
+	err := json.Unmarshal(data, &meta)
+	return meta.ID, meta.Rev, err
+}
+
+// setDocMeta sets _id and _rev of doc, fields doc does not have are left alone.
+func setDocMeta(doc interface{}, id, rev string) error {
+	data, err := json.Marshal(map[string]string{"_id": id, "_rev": rev})
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, doc)
+}
+
+// Put creates a new document or updates an existing document, its _id and _rev
+// are updated after stored. If doc has no _id the UUID generator of the database
+// is used, or the server generates one if not set.
+func (c *Collection[T]) Put(doc *T) (string, string, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return "", "", err
+	}
+	id, _, err := docMeta(data)
+	if err != nil {
+		return "", "", err
+	}
+
+	if id == "" && c.db.uuids != nil {
+		id, err = c.db.uuids.NextUUID()
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	if id == "" {
+		_, data, err = c.db.resource.Post("", nil, data, nil)
+	} else {
+		_, data, err = docResource(c.db.resource, id).Put("", nil, data, nil)
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	result, err := parseData(data)
+	if err != nil {
+		return "", "", err
+	}
+	id, _ = result["id"].(string)
+	rev, _ := result["rev"].(string)
+
+	return id, rev, setDocMeta(doc, id, rev)
+}
+
+// Delete deletes the document, which must have _id and _rev.
+func (c *Collection[T]) Delete(doc *T) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	id, rev, err := docMeta(data)
+	if err != nil {
+		return err
+	}
+	if id == "" || rev == "" {
+		return fmt.Errorf("document _id %q or _rev %q empty", id, rev)
+	}
+	return deleteDoc(docResource(c.db.resource, id), rev)
+}
+
+// Query returns documents using a conditional selector statement in Golang,
+// see Database.Query for the syntax of the arguments.
+func (c *Collection[T]) Query(fields []string, selector string, sorts []string, limit, skip, index interface{}) ([]T, error) {
+	find, err := findQuery(fields, selector, sorts, limit, skip, index)
+	if err != nil {
+		return nil, err
+	}
+
+	_, data, err := c.db.resource.PostJSON("_find", nil, find, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Docs []T `json:"docs"`
+	}
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result.Docs, nil
+}
+
+// View executes a predefined design document view and returns the rows,
+// see Database.View for the arguments. Pass "include_docs" in options to have
+// the documents decoded into T.
+func (c *Collection[T]) View(name string, options map[string]interface{}) ([]TypedRow[T], error) {
+	res := docResource(c.db.resource, designPath(name, "_view"))
+	_, data, err := viewLikeResourceRequest(res, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Rows []TypedRow[T] `json:"rows"`
+	}
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result.Rows, nil
+}
+
+// Changes returns the changes feed made to documents in the database and the
+// last sequence of it. Pass "include_docs" in options to have the documents decoded into T.
+func (c *Collection[T]) Changes(options url.Values) ([]Change[T], Sequence, error) {
+	return changes[T](c.db, options)
+}
+
+func changes[T any](d *Database, options url.Values) ([]Change[T], Sequence, error) {
+	_, data, err := d.resource.GetJSON("_changes", nil, options)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var result struct {
+		Results []Change[T] `json:"results"`
+		LastSeq Sequence    `json:"last_seq"`
+	}
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return nil, "", err
+	}
+	return result.Results, result.LastSeq, nil
+}
@@ -0,0 +1,122 @@
+package couchdb
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+)
+
+type typedPerson struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+	Meta
+}
+
+func TestSequenceUnmarshal(t *testing.T) {
+	var result struct {
+		Number Sequence `json:"number"`
+		String Sequence `json:"string"`
+		Null   Sequence `json:"null"`
+	}
+	err := json.Unmarshal([]byte(`{"number": 42, "string": "7-g1AAAA", "null": null}`), &result)
+	if err != nil {
+		t.Fatal("sequence unmarshal error", err)
+	}
+	if result.Number != "42" || result.String != "7-g1AAAA" || result.Null != "" {
+		t.Errorf("sequences %+v unexpected", result)
+	}
+}
+
+func TestSetDocMeta(t *testing.T) {
+	person := typedPerson{Name: "Tom"}
+	err := setDocMeta(&person, "tom", "1-abc")
+	if err != nil {
+		t.Fatal("set doc meta error", err)
+	}
+	if person.ID != "tom" || person.Rev != "1-abc" || person.Name != "Tom" {
+		t.Errorf("person %+v unexpected", person)
+	}
+}
+
+func TestCollectionCRUD(t *testing.T) {
+	people := NewCollection[typedPerson](testsDB)
+
+	tom := &typedPerson{Name: "Tom", Age: 32}
+	id, rev, err := people.Put(tom)
+	if err != nil {
+		t.Fatal("collection put error", err)
+	}
+	if tom.ID != id || tom.Rev != rev {
+		t.Errorf("put person id %s rev %s want %s %s", tom.ID, tom.Rev, id, rev)
+	}
+
+	tom.Age = 33
+	_, _, err = people.Put(tom)
+	if err != nil {
+		t.Fatal("collection update error", err)
+	}
+
+	got, err := people.Get(id, url.Values{"conflicts": []string{"true"}})
+	if err != nil {
+		t.Fatal("collection get error", err)
+	}
+	if got.Name != "Tom" || got.Age != 33 || got.Rev != tom.Rev {
+		t.Errorf("got person %+v want %+v", got, tom)
+	}
+
+	err = people.Delete(got)
+	if err != nil {
+		t.Fatal("collection delete error", err)
+	}
+
+	_, err = people.Get(id, nil)
+	if err != ErrNotFound {
+		t.Errorf("collection get deleted error %v want %v", err, ErrNotFound)
+	}
+}
+
+func TestCollectionQueryViewChanges(t *testing.T) {
+	type movie struct {
+		Title string `json:"title"`
+		Year  int    `json:"year"`
+		Meta
+	}
+	movies := NewCollection[movie](movieDB)
+
+	docs, err := movies.Query(nil, `year == 1989`, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal("collection query error", err)
+	}
+	if len(docs) == 0 {
+		t.Fatal("collection query returns nothing")
+	}
+	for _, doc := range docs {
+		if doc.Year != 1989 || doc.ID == "" || doc.Rev == "" {
+			t.Errorf("queried movie %+v unexpected", doc)
+		}
+	}
+
+	rows, err := movies.View("_all_docs", map[string]interface{}{"include_docs": true, "limit": 3})
+	if err != nil {
+		t.Fatal("collection view error", err)
+	}
+	if len(rows) != 3 {
+		t.Errorf("collection view rows %d want 3", len(rows))
+	}
+	for _, row := range rows {
+		if row.Doc == nil || row.Doc.ID != row.ID {
+			t.Errorf("view row %+v doc not decoded", row)
+		}
+	}
+
+	changes, lastSeq, err := movies.Changes(url.Values{"include_docs": []string{"true"}})
+	if err != nil {
+		t.Fatal("collection changes error", err)
+	}
+	if len(changes) == 0 || lastSeq == "" {
+		t.Fatalf("collection changes %d last seq %q unexpected", len(changes), lastSeq)
+	}
+	if changes[0].Doc == nil || changes[0].Doc.ID != changes[0].ID {
+		t.Errorf("change %+v doc not decoded", changes[0])
+	}
+}
@@ -0,0 +1,152 @@
+package couchdb
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+)
+
+// ErrNotDeleted for documents which have a non-deleted leaf revision
+var ErrNotDeleted = errors.New("document not deleted")
+
+// tombstone returns the latest deleted leaf revision of the document along with
+// its _revisions history, it fails with ErrNotDeleted if the document is alive.
+func (d *Database) tombstone(docid string) (map[string]interface{}, error) {
+	leaves, err := d.LeafRevisions(docid)
+	if err != nil {
+		return nil, err
+	}
+	if len(leaves) == 0 {
+		return nil, ErrNotFound
+	}
+
+	tree := buildRevTree(leaves)
+	if !tree.Winner.Deleted {
+		return nil, ErrNotDeleted
+	}
+	for _, leaf := range leaves {
+		if leaf["_rev"] == tree.Winner.Rev {
+			return leaf, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// DeletedBody returns the last non-deleted body of a deleted document along with
+// the revision of its tombstone. The ancestors of the tombstone are fetched in a
+// single open_revs request with their attachments, the latest one still available
+// is returned. It fails with ErrNotDeleted if the document is not deleted and with
+// ErrNotFound if all the previous bodies have been compacted away.
+func (d *Database) DeletedBody(docid string) (map[string]interface{}, string, error) {
+	tombstone, err := d.tombstone(docid)
+	if err != nil {
+		return nil, "", err
+	}
+	tombstoneRev, _ := tombstone["_rev"].(string)
+
+	path := revisionPath(tombstone)
+	if len(path) < 2 {
+		return nil, "", ErrNotFound
+	}
+	revs, err := json.Marshal(path[1:])
+	if err != nil {
+		return nil, "", err
+	}
+
+	docs, _, err := d.openRevs(docid, string(revs), url.Values{"attachments": []string{"true"}})
+	if err != nil {
+		return nil, "", err
+	}
+	byRev := map[string]map[string]interface{}{}
+	for _, doc := range docs {
+		rev, _ := doc["_rev"].(string)
+		byRev[rev] = doc
+	}
+
+	for _, rev := range path[1:] {
+		doc, ok := byRev[rev]
+		if !ok {
+			continue
+		}
+		if deleted, _ := doc["_deleted"].(bool); !deleted {
+			return doc, tombstoneRev, nil
+		}
+	}
+	return nil, "", ErrNotFound
+}
+
+// Undelete restores the last non-deleted body of a deleted document, attachments
+// included, as a new revision on top of its tombstone, and returns the new revision.
+func (d *Database) Undelete(docid string) (string, error) {
+	doc, tombstoneRev, err := d.DeletedBody(docid)
+	if err != nil {
+		return "", err
+	}
+
+	delete(doc, "_revisions")
+	doc["_rev"] = tombstoneRev
+	if atts, ok := doc["_attachments"].(map[string]interface{}); ok {
+		for name, att := range atts {
+			a, _ := att.(map[string]interface{})
+			atts[name] = map[string]interface{}{
+				"content_type": a["content_type"],
+				"data":         a["data"],
+			}
+		}
+	}
+
+	err = d.Set(docid, doc)
+	if err != nil {
+		return "", err
+	}
+	rev, _ := doc["_rev"].(string)
+	return rev, nil
+}
+
+// DeletedDoc represents a document deleted, as found in the changes feed.
+type DeletedDoc struct {
+	ID  string
+	Rev string
+	Seq Sequence
+}
+
+// RecentlyDeleted returns up to limit documents deleted most recently, latest
+// first, from the changes feed filtered on tombstones, no limit if not positive.
+func (d *Database) RecentlyDeleted(limit int) ([]DeletedDoc, error) {
+	params := url.Values{}
+	params.Set("filter", "_selector")
+	params.Set("descending", "true")
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	body := map[string]interface{}{
+		"selector": map[string]interface{}{"_deleted": true},
+	}
+
+	_, data, err := d.resource.PostJSON("_changes", nil, body, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Results []Change[map[string]interface{}] `json:"results"`
+	}
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	deleted := []DeletedDoc{}
+	for _, change := range result.Results {
+		if !change.Deleted {
+			continue
+		}
+		doc := DeletedDoc{ID: change.ID, Seq: change.Seq}
+		if len(change.Changes) > 0 {
+			doc.Rev = change.Changes[0].Rev
+		}
+		deleted = append(deleted, doc)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,61 @@
+package couchdb
+
+import (
+	"testing"
+)
+
+func TestUndelete(t *testing.T) {
+	doc := map[string]interface{}{
+		"_id":  "undelete",
+		"name": "precious",
+		"_attachments": map[string]interface{}{
+			"a.txt": Attachment{ContentType: "text/plain", Data: []byte("kept")},
+		},
+	}
+	_, rev, err := testsDB.Save(doc, nil)
+	if err != nil {
+		t.Fatal("db save error", err)
+	}
+
+	_, _, err = testsDB.DeletedBody("undelete")
+	if err != ErrNotDeleted {
+		t.Errorf("deleted body error %v want %v", err, ErrNotDeleted)
+	}
+
+	err = testsDB.DeleteDoc(doc)
+	if err != nil {
+		t.Fatal("db delete doc error", err)
+	}
+
+	body, tombstoneRev, err := testsDB.DeletedBody("undelete")
+	if err != nil {
+		t.Fatal("db deleted body error", err)
+	}
+	if body["_rev"] != rev || body["name"] != "precious" || tombstoneRev == rev {
+		t.Errorf("deleted body %v tombstone %s want rev %s", body, tombstoneRev, rev)
+	}
+
+	deleted, err := testsDB.RecentlyDeleted(10)
+	if err != nil {
+		t.Fatal("db recently deleted error", err)
+	}
+	if len(deleted) == 0 || deleted[0].ID != "undelete" || deleted[0].Rev != tombstoneRev {
+		t.Errorf("recently deleted %v want undelete first", deleted)
+	}
+
+	_, err = testsDB.Undelete("undelete")
+	if err != nil {
+		t.Fatal("db undelete error", err)
+	}
+	restored, err := testsDB.Get("undelete", nil)
+	if err != nil {
+		t.Fatal("db get error", err)
+	}
+	if restored["name"] != "precious" {
+		t.Errorf("restored doc %v want name precious", restored)
+	}
+	data, err := testsDB.GetAttachmentID("undelete", "a.txt")
+	if err != nil || string(data) != "kept" {
+		t.Errorf("restored attachment %q error %v want kept", data, err)
+	}
+}
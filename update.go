@@ -0,0 +1,158 @@
+package couchdb
+
+import (
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy controls how updates failed with ErrConflict are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first one.
+	MaxAttempts int
+	// Backoff is the delay before the first retry, doubled on each retry.
+	Backoff time.Duration
+	// MaxBackoff caps the delay between retries.
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy is used when no RetryPolicy is given.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 10,
+	Backoff:     10 * time.Millisecond,
+	MaxBackoff:  time.Second,
+}
+
+// retry calls fn until it returns an error other than ErrConflict or the
+// attempts are used up, sleeping a jittered exponential backoff in between.
+func (p *RetryPolicy) retry(fn func() error) error {
+	if p == nil {
+		p = &DefaultRetryPolicy
+	}
+
+	delay := p.Backoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = fn()
+		if err != ErrConflict || attempt >= p.MaxAttempts {
+			return err
+		}
+
+		if delay > 0 {
+			// sleep in [delay/2, delay) so that competing writers spread out
+			time.Sleep(delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1)))
+			delay *= 2
+			if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+				delay = p.MaxBackoff
+			}
+		}
+	}
+}
+
+// UpdateFunc fetches the latest revision of the document with the specified ID,
+// applies mutate to it and saves it. When the save fails with ErrConflict the
+// document is fetched again and mutate reapplied, as many times as policy allows,
+// DefaultRetryPolicy is used if policy is nil. If the document does not exist,
+// mutate gets a new document containing only _id. An error returned by mutate
+// aborts the update. Returns the new revision of the document.
+func (d *Database) UpdateFunc(docid string, policy *RetryPolicy, mutate func(doc map[string]interface{}) error) (string, error) {
+	var rev string
+	err := policy.retry(func() error {
+		doc, err := d.Get(docid, nil)
+		if err == ErrNotFound {
+			doc = map[string]interface{}{"_id": docid}
+		} else if err != nil {
+			return err
+		}
+
+		err = mutate(doc)
+		if err != nil {
+			return err
+		}
+
+		_, rev, err = d.Save(doc, nil)
+		return err
+	})
+	return rev, err
+}
+
+// UpdateRetry performs a bulk update like Update, then retries the documents
+// failed with ErrConflict as many times as policy allows, DefaultRetryPolicy is used
+// if policy is nil. Before each retry the current revisions of the conflicted
+// documents are fetched in one _all_docs request and set as their _rev, so their
+// content overwrites the one in the database, use UpdateFunc to merge instead.
+// Documents still conflicted once the attempts are used up keep ErrConflict
+// in their results.
+func (d *Database) UpdateRetry(docs []map[string]interface{}, policy *RetryPolicy) ([]UpdateResult, error) {
+	results := make([]UpdateResult, len(docs))
+	pending := make([]int, len(docs))
+	for i := range docs {
+		pending[i] = i
+	}
+
+	first := true
+	err := policy.retry(func() error {
+		batch := make([]map[string]interface{}, len(pending))
+		for j, i := range pending {
+			batch[j] = docs[i]
+		}
+
+		if !first {
+			err := d.refreshRevs(batch)
+			if err != nil {
+				return err
+			}
+		}
+		first = false
+
+		batchResults, err := d.Update(batch, nil)
+		if err != nil {
+			return err
+		}
+
+		conflicted := []int{}
+		for j, i := range pending {
+			results[i] = batchResults[j]
+			if batchResults[j].Err == ErrConflict {
+				conflicted = append(conflicted, i)
+			}
+		}
+		pending = conflicted
+		if len(pending) > 0 {
+			return ErrConflict
+		}
+		return nil
+	})
+	if err != nil && err != ErrConflict {
+		return nil, err
+	}
+	return results, nil
+}
+
+// refreshRevs sets the _rev of docs to their current revisions, removing it
+// from the documents which do not exist.
+func (d *Database) refreshRevs(docs []map[string]interface{}) error {
+	keys := make([]string, len(docs))
+	for i, doc := range docs {
+		keys[i], _ = doc["_id"].(string)
+	}
+
+	result, err := d.AllDocs(&AllDocsOptions{Keys: keys})
+	if err != nil {
+		return err
+	}
+
+	revs := map[string]string{}
+	for _, row := range result.Rows {
+		if row.Error == "" {
+			revs[row.Key] = row.Value.Rev
+		}
+	}
+	for i, doc := range docs {
+		if rev, ok := revs[keys[i]]; ok {
+			doc["_rev"] = rev
+		} else {
+			delete(doc, "_rev")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package couchdb
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	policy := &RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}
+
+	attempts := 0
+	err := policy.retry(func() error {
+		attempts++
+		return ErrConflict
+	})
+	if err != ErrConflict || attempts != 3 {
+		t.Errorf("retry error %v attempts %d want %v 3", err, attempts, ErrConflict)
+	}
+
+	attempts = 0
+	errMutate := errors.New("mutate failed")
+	err = policy.retry(func() error {
+		attempts++
+		return errMutate
+	})
+	if err != errMutate || attempts != 1 {
+		t.Errorf("retry error %v attempts %d want %v 1", err, attempts, errMutate)
+	}
+
+	attempts = 0
+	err = policy.retry(func() error {
+		attempts++
+		if attempts < 2 {
+			return ErrConflict
+		}
+		return nil
+	})
+	if err != nil || attempts != 2 {
+		t.Errorf("retry error %v attempts %d want nil 2", err, attempts)
+	}
+}
+
+func TestUpdateFuncConcurrent(t *testing.T) {
+	policy := &RetryPolicy{MaxAttempts: 100, Backoff: time.Millisecond, MaxBackoff: 50 * time.Millisecond}
+	increment := func(doc map[string]interface{}) error {
+		count, _ := doc["count"].(float64)
+		doc["count"] = count + 1
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := testsDB.UpdateFunc("golang-counter", policy, increment)
+			if err != nil {
+				t.Error("db update func error", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	doc, err := testsDB.Get("golang-counter", nil)
+	if err != nil {
+		t.Fatal("db get error", err)
+	}
+	if doc["count"].(float64) != 10 {
+		t.Errorf("count %v want 10", doc["count"])
+	}
+}
+
+func TestUpdateFuncAbort(t *testing.T) {
+	errAbort := errors.New("abort")
+	_, err := testsDB.UpdateFunc("golang-aborted", nil, func(doc map[string]interface{}) error {
+		return errAbort
+	})
+	if err != errAbort {
+		t.Errorf("db update func error %v want %v", err, errAbort)
+	}
+
+	err = testsDB.Contains("golang-aborted")
+	if err != ErrNotFound {
+		t.Errorf("db contains error %v want %v", err, ErrNotFound)
+	}
+}
+
+func TestUpdateRetry(t *testing.T) {
+	docs := []map[string]interface{}{
+		{"_id": "retry-a", "value": 1},
+		{"_id": "retry-b", "value": 1},
+	}
+	_, err := testsDB.Update(docs, nil)
+	if err != nil {
+		t.Fatal("db update error", err)
+	}
+
+	// make retry-a stale
+	_, err = testsDB.UpdateFunc("retry-a", nil, func(doc map[string]interface{}) error {
+		doc["value"] = 2
+		return nil
+	})
+	if err != nil {
+		t.Fatal("db update func error", err)
+	}
+
+	docs[0]["value"], docs[1]["value"] = 3, 3
+	results, err := testsDB.Update(docs, nil)
+	if err != nil {
+		t.Fatal("db update error", err)
+	}
+	if results[0].Err != ErrConflict || results[0].Error != "conflict" || results[0].Reason == "" {
+		t.Errorf("update result %v want conflict with reason", results[0])
+	}
+
+	results, err = testsDB.UpdateRetry(docs, nil)
+	if err != nil {
+		t.Fatal("db update retry error", err)
+	}
+	for _, result := range results {
+		if result.Err != nil {
+			t.Errorf("update retry result %v", result)
+		}
+	}
+	doc, err := testsDB.Get("retry-a", nil)
+	if err != nil {
+		t.Fatal("db get error", err)
+	}
+	if doc["value"].(float64) != 3 {
+		t.Errorf("retry-a value %v want 3", doc["value"])
+	}
+}
+
+func TestUpdateNewEditsFalse(t *testing.T) {
+	docs := []map[string]interface{}{
+		{"_id": "replicated", "_rev": "1-abc", "value": 1},
+	}
+	results, err := testsDB.Update(docs, map[string]interface{}{"new_edits": false})
+	if err != nil {
+		t.Fatal("db update error", err)
+	}
+	if len(results) != 1 || results[0].Err != nil || results[0].ID != "replicated" || results[0].Rev != "1-abc" {
+		t.Errorf("update results %v want replicated 1-abc", results)
+	}
+
+	doc, err := testsDB.Get("replicated", nil)
+	if err != nil {
+		t.Fatal("db get error", err)
+	}
+	if doc["_rev"] != "1-abc" {
+		t.Errorf("replicated rev %v want 1-abc", doc["_rev"])
+	}
+}
+
+func TestParseReplicatedResults(t *testing.T) {
+	docs := []map[string]interface{}{
+		{"_id": "a", "_rev": "1-a"},
+		{"_id": "a", "_rev": "2-a"},
+		{"_id": "b", "_rev": "1-b"},
+		{"_id": "b", "_rev": "2-b"},
+	}
+	data := []byte(`[
+		{"id": "a", "rev": "2-a", "error": "forbidden", "reason": "denied"},
+		{"id": "b", "error": "forbidden", "reason": "denied"}
+	]`)
+	results, err := parseReplicatedResults(docs, data)
+	if err != nil {
+		t.Fatal("parse replicated results error", err)
+	}
+	want := []error{nil, ErrForbidden, ErrForbidden, nil}
+	for i, result := range results {
+		if result.Err != want[i] {
+			t.Errorf("results[%d] error %v want %v", i, result.Err, want[i])
+		}
+	}
+	if results[0].Rev != "1-a" || results[3].Rev != "2-b" {
+		t.Errorf("results %v want revs 1-a and 2-b", results)
+	}
+}
@@ -0,0 +1,165 @@
+package couchdb
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+)
+
+const (
+	// sequential ids share a prefix until the suffix reaches this value
+	maxSequentialSuffix = 0xfff000
+	// the suffix of sequential ids increases by a random step in [1, maxSequentialStep]
+	maxSequentialStep = 0xffd
+	// defaultUUIDBatch is the number of UUIDs BatchGenerator fetches per request by default
+	defaultUUIDBatch = 100
+)
+
+// UUIDGenerator generates document IDs on the client side. Database.Save uses it,
+// if set by Database.SetUUIDGenerator, to assign IDs to documents with no _id.
+type UUIDGenerator interface {
+	NextUUID() (string, error)
+}
+
+// randomHex returns n random bytes in hex.
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// utcPrefix returns the current microseconds since epoch as 14 hex digits.
+func utcPrefix() string {
+	return fmt.Sprintf("%014x", time.Now().UnixNano()/int64(time.Microsecond))
+}
+
+// SequentialGenerator implements the "sequential" algorithm of CouchDB: a random
+// 26 hex digits prefix followed by a 6 hex digits suffix increased by a random step,
+// the prefix is regenerated when the suffix overflows. IDs generated are monotonically
+// increasing until then, which keeps writes local in the b-tree.
+type SequentialGenerator struct {
+	mu     sync.Mutex
+	prefix string
+	suffix int64
+}
+
+// NewSequentialGenerator returns a newly-created *SequentialGenerator.
+func NewSequentialGenerator() *SequentialGenerator {
+	return &SequentialGenerator{}
+}
+
+func sequentialStep() (int64, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(maxSequentialStep))
+	if err != nil {
+		return 0, err
+	}
+	return n.Int64() + 1, nil
+}
+
+// NextUUID returns the next sequential UUID.
+func (g *SequentialGenerator) NextUUID() (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	step, err := sequentialStep()
+	if err != nil {
+		return "", err
+	}
+
+	g.suffix += step
+	if g.prefix == "" || g.suffix >= maxSequentialSuffix {
+		g.prefix, err = randomHex(13)
+		if err != nil {
+			g.prefix = ""
+			return "", err
+		}
+		g.suffix = step
+	}
+	return fmt.Sprintf("%s%06x", g.prefix, g.suffix), nil
+}
+
+// UTCRandomGenerator implements the "utc_random" algorithm of CouchDB: the
+// microseconds since epoch in 14 hex digits followed by 18 random hex digits.
+type UTCRandomGenerator struct{}
+
+// NewUTCRandomGenerator returns a newly-created *UTCRandomGenerator.
+func NewUTCRandomGenerator() *UTCRandomGenerator {
+	return &UTCRandomGenerator{}
+}
+
+// NextUUID returns the next utc_random UUID.
+func (g *UTCRandomGenerator) NextUUID() (string, error) {
+	suffix, err := randomHex(9)
+	if err != nil {
+		return "", err
+	}
+	return utcPrefix() + suffix, nil
+}
+
+// UTCIDGenerator implements the "utc_id" algorithm of CouchDB: the microseconds
+// since epoch in 14 hex digits followed by a fixed suffix.
+type UTCIDGenerator struct {
+	suffix string
+}
+
+// NewUTCIDGenerator returns a newly-created *UTCIDGenerator appending suffix to every ID,
+// which is the utc_id_suffix setting of CouchDB.
+func NewUTCIDGenerator(suffix string) *UTCIDGenerator {
+	return &UTCIDGenerator{
+		suffix: suffix,
+	}
+}
+
+// NextUUID returns the next utc_id UUID.
+func (g *UTCIDGenerator) NextUUID() (string, error) {
+	return utcPrefix() + g.suffix, nil
+}
+
+// BatchGenerator hands out UUIDs generated by the server, fetching them from
+// Server.UUIDs in batches so that most calls need no round trip.
+type BatchGenerator struct {
+	mu     sync.Mutex
+	server *Server
+	batch  int
+	uuids  []string
+}
+
+// NewBatchGenerator returns a newly-created *BatchGenerator fetching batch UUIDs
+// per request from server, 100 if batch is not positive.
+func NewBatchGenerator(server *Server, batch int) *BatchGenerator {
+	if batch <= 0 {
+		batch = defaultUUIDBatch
+	}
+	return &BatchGenerator{
+		server: server,
+		batch:  batch,
+	}
+}
+
+// NextUUID returns the next UUID, fetching another batch if all have been used.
+func (g *BatchGenerator) NextUUID() (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(g.uuids) == 0 {
+		uuids, err := g.server.UUIDs(g.batch)
+		if err != nil {
+			return "", err
+		}
+		g.uuids = uuids
+	}
+
+	if len(g.uuids) == 0 {
+		return "", fmt.Errorf("no uuids returned by %s", g.server)
+	}
+
+	uuid := g.uuids[0]
+	g.uuids = g.uuids[1:]
+	return uuid, nil
+}
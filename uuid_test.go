@@ -0,0 +1,110 @@
+package couchdb
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var hexUUID = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+func TestSequentialGenerator(t *testing.T) {
+	gen := NewSequentialGenerator()
+	prev := ""
+	for i := 0; i < 1000; i++ {
+		uuid, err := gen.NextUUID()
+		if err != nil {
+			t.Fatal("sequential uuid error", err)
+		}
+		if !hexUUID.MatchString(uuid) {
+			t.Fatalf("sequential uuid %s not 32 hex digits", uuid)
+		}
+		if prev != "" && uuid[:26] == prev[:26] && uuid <= prev {
+			t.Fatalf("sequential uuid %s not greater than %s", uuid, prev)
+		}
+		prev = uuid
+	}
+}
+
+func TestSequentialGeneratorOverflow(t *testing.T) {
+	gen := NewSequentialGenerator()
+	first, err := gen.NextUUID()
+	if err != nil {
+		t.Fatal("sequential uuid error", err)
+	}
+	gen.suffix = maxSequentialSuffix - 1
+	next, err := gen.NextUUID()
+	if err != nil {
+		t.Fatal("sequential uuid error", err)
+	}
+	if next[:26] == first[:26] {
+		t.Errorf("sequential uuid prefix %s not regenerated after overflow", next[:26])
+	}
+}
+
+func TestUTCGenerators(t *testing.T) {
+	random := NewUTCRandomGenerator()
+	first, err := random.NextUUID()
+	if err != nil {
+		t.Fatal("utc_random uuid error", err)
+	}
+	second, err := random.NextUUID()
+	if err != nil {
+		t.Fatal("utc_random uuid error", err)
+	}
+	if !hexUUID.MatchString(first) || !hexUUID.MatchString(second) {
+		t.Errorf("utc_random uuids %s %s not 32 hex digits", first, second)
+	}
+	if second[:14] < first[:14] {
+		t.Errorf("utc_random uuid %s earlier than %s", second, first)
+	}
+
+	id, err := NewUTCIDGenerator("-node1").NextUUID()
+	if err != nil {
+		t.Fatal("utc_id uuid error", err)
+	}
+	if len(id) != 20 || !strings.HasSuffix(id, "-node1") {
+		t.Errorf("utc_id uuid %s want 14 hex digits with suffix -node1", id)
+	}
+}
+
+func TestBatchGenerator(t *testing.T) {
+	gen := NewBatchGenerator(server, 3)
+	seen := map[string]bool{}
+	for i := 0; i < 7; i++ {
+		uuid, err := gen.NextUUID()
+		if err != nil {
+			t.Fatal("batch uuid error", err)
+		}
+		if seen[uuid] {
+			t.Fatalf("batch uuid %s duplicated", uuid)
+		}
+		seen[uuid] = true
+	}
+}
+
+func TestSaveWithUUIDGenerator(t *testing.T) {
+	db, err := server.Get("golang-tests")
+	if err != nil {
+		t.Fatal("server get error", err)
+	}
+	db.SetUUIDGenerator(NewUTCIDGenerator("-golang"))
+
+	doc := map[string]interface{}{"type": "Person", "name": "Tom"}
+	id, _, err := db.Save(doc, nil)
+	if err != nil {
+		t.Fatal("db save error", err)
+	}
+	if !strings.HasSuffix(id, "-golang") {
+		t.Errorf("doc id %s want suffix -golang", id)
+	}
+
+	user := &User{Name: "Tom", Age: 32}
+	err = Store(db, user)
+	if err != nil {
+		t.Fatal("store error", err)
+	}
+	if !strings.HasSuffix(user.GetID(), "-golang") {
+		t.Errorf("stored id %s want suffix -golang", user.GetID())
+	}
+}